@@ -0,0 +1,107 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// cannotStartValue returns whether r is a character which is not whitespace
+// and cannot start a value, like a colon or a closing bracket.
+func cannotStartValue(r rune) bool {
+	return IsDelimiter(r) && !IsQuote(r) && r != codeNewline &&
+		r != codeOpeningBrace && r != codeOpeningBracket
+}
+
+// skipUnexpected skips the characters for which skip returns true, together
+// with the whitespace and comments between them. Only used with
+// WithBestEffort.
+func (t *RepairText) skipUnexpected(skip func(rune) bool) {
+	for {
+		start := t.i
+		for !t.atEnd() && skip(t.text[t.i]) {
+			t.i++
+		}
+		if t.i == start {
+			return
+		}
+		t.skipText(start, t.i)
+		t.parseWhitespaceAndSkipComments()
+	}
+}
+
+// skipText records the text between start and end as skipped, and moves past
+// it.
+func (t *RepairText) skipText(start, end int) {
+	t.report(RepairSkippedText, start, end)
+	t.token(TokenSymbol, start, end, true)
+	t.i = end
+}
+
+// insertNull appends null for a missing value.
+func (t *RepairText) insertNull() {
+	t.reportInsert(RepairMissingValue, &t.output, t.output.Len())
+	t.insertToken(TokenKeyword, "null", &t.output, t.output.Len())
+	t.output.appendString(-1, "null")
+	t.emit(func(h Handler) { h.OnValue(NodeNull, "null") })
+}
+
+// quoteInvalid replaces an output which is still not valid JSON, because the
+// text is beyond repair, by the whole text as a string. Only used with
+// WithBestEffort. With WithComments, the output is valid when it is valid
+// without its comments.
+func (t *RepairText) quoteInvalid() {
+	output := t.output.String()
+	if t.opts.comments {
+		output = withoutComments(output)
+	}
+	if json.Valid([]byte(output)) {
+		return
+	}
+	// the text after the first value is left to the caller
+	end := len(t.text)
+	if t.opts.firstValue {
+		end = t.i
+	}
+	t.output = newOutputBuffer()
+	t.output.appendString(-1, quote(string(t.text[:end])))
+	t.repairs = nil
+	t.partial = nil
+	t.incomplete = nil
+	t.stable = 0
+	t.report(RepairUnquotedString, 0, end)
+}
+
+// withoutComments returns the JSONC text s with its comments replaced by
+// spaces.
+func withoutComments(s string) string {
+	b := []byte(s)
+	inString := false
+	for i := 0; i < len(b); i++ {
+		switch {
+		case inString:
+			if b[i] == '\\' {
+				i++
+			} else if b[i] == '"' {
+				inString = false
+			}
+		case b[i] == '"':
+			inString = true
+		case b[i] == '/' && i+1 < len(b) && (b[i+1] == '/' || b[i+1] == '*'):
+			end := "\n"
+			if b[i+1] == '*' {
+				end = "*/"
+			}
+			n := strings.Index(s[i+2:], end)
+			if n < 0 {
+				n = len(s) - i - 2
+			} else if end == "*/" {
+				n += len(end)
+			}
+			for j := i; j < i+2+n; j++ {
+				b[j] = ' '
+			}
+			i += 1 + n
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,105 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBestEffort(t *testing.T) {
+	ts := []struct {
+		Input   string
+		Output  string
+		Skipped [][2]int
+	}{
+		{``, `null`, nil},
+		{`{:2}`, `{"2":null}`, [][2]int{{1, 2}}},
+		{`{"a" ]`, `{"a": null}`, nil},
+		{`{"a":2}foo`, `{"a":2}`, [][2]int{{7, 10}}},
+		{`2.3.4`, `2.3`, [][2]int{{3, 5}}},
+		{`[2e,`, `["2e"]`, nil},
+		{`[1,,2]`, `[1,2]`, [][2]int{{3, 4}}},
+		{`}{"a":1}`, `{"a":1}`, [][2]int{{0, 1}}},
+		{`"\u26"`, `"u26"`, nil},
+		{"\"a\x01b\"", `"a\u0001b"`, nil},
+		{"\"a\\\nb\"", `"a\nb"`, nil},
+		{`\abc`, `"\\abc"`, nil},
+		{`.5`, `".5"`, nil},
+		{`{undefined: 1}`, `{"undefined": 1}`, nil},
+		{`{a(1): 2}`, `{"a":1, "2":null}`, [][2]int{{2, 3}, {4, 6}}},
+	}
+	for _, tt := range ts {
+		res, err := Repair(tt.Input, WithBestEffort())
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.Input, err)
+			continue
+		}
+		if res.Output != tt.Output {
+			t.Errorf("%q: expected %s, got %s", tt.Input, tt.Output, res.Output)
+		}
+		var skipped [][2]int
+		for _, r := range res.Repairs {
+			if r.Kind == RepairSkippedText {
+				skipped = append(skipped, [2]int{r.Start, r.End})
+			}
+		}
+		if !reflect.DeepEqual(skipped, tt.Skipped) {
+			t.Errorf("%q: expected skipped %v, got %v", tt.Input, tt.Skipped, skipped)
+		}
+	}
+}
+
+func TestBestEffortIsValid(t *testing.T) {
+	chars := []rune(`{}[]():,"'+-1aeE.\ /*` + "\n\x01")
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 20000; n++ {
+		text := make([]rune, r.Intn(14))
+		for i := range text {
+			text[i] = chars[r.Intn(len(chars))]
+		}
+		res, err := Repair(string(text), WithBestEffort())
+		if err != nil || !json.Valid([]byte(res.Output)) {
+			t.Fatalf("%q: expected valid JSON, got %v %v", string(text), res, err)
+		}
+		res, err = Repair(string(text), WithBestEffort(), WithComments())
+		if err != nil || !json.Valid([]byte(withoutComments(res.Output))) {
+			t.Fatalf("%q: expected valid JSONC, got %v %v", string(text), res, err)
+		}
+	}
+}
+
+func TestBestEffortComments(t *testing.T) {
+	for text, expected := range map[string]string{
+		`{"a": 1 /* c */,}`: `{"a": 1 /* c */}`,
+		"[1 // c":           "[1] // c\n",
+		`/*/a}1`:            `"/*/a}1"`,
+	} {
+		res, err := Repair(text, WithBestEffort(), WithComments())
+		if err != nil || res.Output != expected {
+			t.Errorf("%q: expected %s, got %v %v", text, expected, res, err)
+		}
+	}
+}
+
+func TestBestEffortDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`}{"a":1} [1,,2] {a b`), WithBestEffort())
+	var values []any
+	for {
+		var v any
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, v)
+	}
+	expected := []any{map[string]any{"a": 1.0}, []any{1.0, 2.0}, map[string]any{"a b": nil}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+}
@@ -0,0 +1,100 @@
+package jsonrepair
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// maxStringEnds is the number of later quotes which are tried as the
+	// end of a string by RepairCandidates.
+	maxStringEnds = 3
+	// candidateRuns is the number of repairs RepairCandidates tries per
+	// requested candidate.
+	candidateRuns = 8
+)
+
+// RepairCandidates repairs the given text like Repair, and returns up to n
+// alternative repaired documents, ranked by Result.Confidence, most likely
+// first. Alternatives arise where the text is ambiguous, like a string with
+// quotes inside: `{"a": "foo "bar" baz"}` can be repaired to
+// `{"a": "foo ","bar": "baz"}` or to `{"a": "foo \"bar\" baz"}`. When the
+// alternatives are equally likely, the result of Repair comes first.
+func RepairCandidates(text string, n int, opts ...Option) ([]*Result, error) {
+	start := time.Now()
+	var (
+		candidates []*Result
+		firstErr   error
+		seen       = map[string]bool{}
+		queue      = [][]int{{}}
+	)
+	for runs := 0; len(queue) > 0 && runs < n*candidateRuns; runs++ {
+		choices := queue[0]
+		queue = queue[1:]
+
+		t := newRepairText(text, opts)
+		t.choices = choices
+		t.decisions = []int{}
+		res, err := t.run()
+		if runs == 0 {
+			t.observe(len(text), start, err)
+			firstErr = err
+		}
+		if err == nil && !seen[res.Output] {
+			seen[res.Output] = true
+			candidates = append(candidates, res)
+		}
+		// vary every decision after the forced ones
+		for k := len(choices); k < len(t.decisions); k++ {
+			for alt := 1; alt < t.decisions[k]; alt++ {
+				next := make([]int, k+1)
+				copy(next, choices)
+				next[k] = alt
+				queue = append(queue, next)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, firstErr
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates, nil
+}
+
+// choose returns which of n alternatives to take at the next ambiguous point
+// of the text, 0 being the default.
+func (t *RepairText) choose(n int) int {
+	if t.decisions == nil {
+		return 0
+	}
+	k := len(t.decisions)
+	t.decisions = append(t.decisions, n)
+	if k < len(t.choices) && t.choices[k] < n {
+		return t.choices[k]
+	}
+	return 0
+}
+
+// laterStringEnds returns the positions of the quotes after the current one
+// which may end the string being parsed, because they are followed by a
+// delimiter. Only used by RepairCandidates.
+func (t *RepairText) laterStringEnds(isEndQuote func(rune) bool) []int {
+	var ends []int
+	if t.decisions == nil {
+		return ends
+	}
+	for j := t.i + 1; j < len(t.text) && len(ends) < maxStringEnds; j++ {
+		if !isEndQuote(t.text[j]) || t.text[j-1] == codeBackslash {
+			continue
+		}
+		if next := nextNonWhiteSpaceCharacter(t.text, j+1); next == -1 || IsDelimiter(next) {
+			ends = append(ends, j)
+		}
+	}
+	return ends
+}
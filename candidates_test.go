@@ -0,0 +1,39 @@
+package jsonrepair
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepairCandidates(t *testing.T) {
+	ts := []struct {
+		Input   string
+		N       int
+		Outputs []string
+	}{
+		{`{"a":1}`, 3, []string{`{"a":1}`}},
+		{`{"a": "foo "bar" baz"}`, 3, []string{`{"a": "foo \"bar\" baz"}`, `{"a": "foo ","bar": "baz"}`}},
+		{`{"a": "x, "b": "y"}`, 3, []string{`{"a": "x", "b": "y"}`, `{"a": "x, ","b": "y"}`, `{"a": "x, \"b\": \"y"}`}},
+		{`{"a": "x, "b": "y"}`, 1, []string{`{"a": "x", "b": "y"}`}},
+	}
+	for _, tt := range ts {
+		candidates, err := RepairCandidates(tt.Input, tt.N)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.Input, err)
+		}
+		var outputs []string
+		for i, c := range candidates {
+			outputs = append(outputs, c.Output)
+			if i > 0 && c.Confidence > candidates[i-1].Confidence {
+				t.Errorf("%s: candidates are not ranked: %v", tt.Input, outputs)
+			}
+		}
+		if !reflect.DeepEqual(outputs, tt.Outputs) {
+			t.Errorf("%s: expected %q, got %q", tt.Input, tt.Outputs, outputs)
+		}
+	}
+
+	if _, err := RepairCandidates(`{:}`, 3); err == nil || err.Error() != "Object key expected at position 1" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
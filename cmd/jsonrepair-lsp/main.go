@@ -0,0 +1,19 @@
+// Command jsonrepair-lsp is a Language Server Protocol server for JSON files.
+// It publishes a diagnostic for every repair jsonrepair would apply to a
+// document, and offers code actions to apply one or all of them.
+//
+// The server communicates over stdin and stdout.
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	log.SetOutput(os.Stderr)
+	log.SetPrefix("jsonrepair-lsp: ")
+	if err := newServer(os.Stdin, os.Stdout).run(); err != nil {
+		log.Fatal(err)
+	}
+}
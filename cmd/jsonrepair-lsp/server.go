@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"strconv"
+
+	"github.com/wakenmeng/jsonrepair"
+)
+
+const (
+	severityError   = 1
+	severityWarning = 2
+
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type (
+	message struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id,omitempty"`
+		Method  string          `json:"method,omitempty"`
+		Params  json.RawMessage `json:"params,omitempty"`
+	}
+
+	response struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  any             `json:"result"`
+		Error   *responseError  `json:"error,omitempty"`
+	}
+
+	responseError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+
+	notification struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Params  any    `json:"params"`
+	}
+
+	textDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	}
+
+	diagnostic struct {
+		Range    jsonrepair.Range `json:"range"`
+		Severity int              `json:"severity"`
+		Code     string           `json:"code,omitempty"`
+		Source   string           `json:"source"`
+		Message  string           `json:"message"`
+	}
+
+	codeAction struct {
+		Title       string        `json:"title"`
+		Kind        string        `json:"kind"`
+		Diagnostics []diagnostic  `json:"diagnostics,omitempty"`
+		IsPreferred bool          `json:"isPreferred,omitempty"`
+		Edit        workspaceEdit `json:"edit"`
+	}
+
+	workspaceEdit struct {
+		Changes map[string][]jsonrepair.TextEdit `json:"changes"`
+	}
+)
+
+// server is a Language Server Protocol server which keeps the open documents
+// in memory.
+type server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]string
+	encoding jsonrepair.PositionEncoding
+}
+
+func newServer(in io.Reader, out io.Writer) *server {
+	return &server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: map[string]string{},
+	}
+}
+
+// rpcError is an error in a message of the client, which is answered with an
+// error response instead of stopping the server.
+type rpcError struct {
+	code int
+	err  error
+}
+
+func (e *rpcError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rpcError) Unwrap() error {
+	return e.err
+}
+
+func unmarshalParams(msg *message, v any) error {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &rpcError{code: codeInvalidParams, err: fmt.Errorf("invalid params of %s: %w", msg.Method, err)}
+	}
+	return nil
+}
+
+// run serves requests until the client sends exit or closes the input. It
+// only returns an error when reading or writing fails.
+func (s *server) run() error {
+	for {
+		msg, err := s.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err == nil && msg.Method == "exit" {
+			return nil
+		}
+		if err == nil {
+			err = s.handle(msg)
+		}
+		var rpcErr *rpcError
+		switch {
+		case err == nil:
+		case !errors.As(err, &rpcErr):
+			return err
+		case msg == nil || msg.ID == nil:
+			// only requests have a response
+			log.Print(err)
+		default:
+			if err := s.write(response{
+				JSONRPC: "2.0",
+				ID:      msg.ID,
+				Error:   &responseError{Code: rpcErr.code, Message: err.Error()},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *server) handle(msg *message) error {
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			Capabilities struct {
+				General struct {
+					PositionEncodings []string `json:"positionEncodings"`
+				} `json:"general"`
+			} `json:"capabilities"`
+		}
+		if err := unmarshalParams(msg, &params); err != nil {
+			return err
+		}
+		encoding := "utf-16"
+		for _, e := range params.Capabilities.General.PositionEncodings {
+			if e == "utf-8" {
+				encoding = e
+				s.encoding = jsonrepair.PositionEncodingUTF8
+			}
+		}
+		return s.respond(msg.ID, map[string]any{
+			"capabilities": map[string]any{
+				"positionEncoding": encoding,
+				"textDocumentSync": 1, // full
+				"codeActionProvider": map[string]any{
+					"codeActionKinds": []string{"quickfix", "source.fixAll"},
+				},
+			},
+			"serverInfo": map[string]string{"name": "jsonrepair-lsp"},
+		})
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument textDocument `json:"textDocument"`
+		}
+		if err := unmarshalParams(msg, &params); err != nil {
+			return err
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument   textDocument `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := unmarshalParams(msg, &params); err != nil {
+			return err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+		}
+		return s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument textDocument `json:"textDocument"`
+		}
+		if err := unmarshalParams(msg, &params); err != nil {
+			return err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics", map[string]any{
+			"uri":         params.TextDocument.URI,
+			"diagnostics": []diagnostic{},
+		})
+	case "textDocument/codeAction":
+		var params struct {
+			TextDocument textDocument     `json:"textDocument"`
+			Range        jsonrepair.Range `json:"range"`
+		}
+		if err := unmarshalParams(msg, &params); err != nil {
+			return err
+		}
+		return s.respond(msg.ID, s.codeActions(params.TextDocument.URI, params.Range))
+	case "shutdown":
+		return s.respond(msg.ID, nil)
+	}
+	if msg.ID != nil {
+		return s.write(response{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error:   &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method},
+		})
+	}
+	return nil
+}
+
+// diagnostics returns a diagnostic for every fix of the document, or a single
+// diagnostic when the document cannot be repaired.
+func (s *server) diagnostics(text string) ([]jsonrepair.Fix, []diagnostic) {
+	fixes, err := jsonrepair.Fixes(text, s.encoding)
+	if err != nil {
+		var repairErr jsonrepair.JSONRepairError
+		pos := jsonrepair.Position{}
+		if errors.As(err, &repairErr) {
+			pos = jsonrepair.OffsetPosition(text, repairErr.Position, s.encoding)
+		}
+		return nil, []diagnostic{{
+			Range:    jsonrepair.Range{Start: pos, End: pos},
+			Severity: severityError,
+			Source:   "jsonrepair",
+			Message:  err.Error(),
+		}}
+	}
+	diagnostics := []diagnostic{}
+	for _, fix := range fixes {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    fix.Range,
+			Severity: severityWarning,
+			Code:     fix.Kind.String(),
+			Source:   "jsonrepair",
+			Message:  "JSON issue: " + fix.Kind.String(),
+		})
+	}
+	return fixes, diagnostics
+}
+
+func (s *server) publishDiagnostics(uri string) error {
+	_, diagnostics := s.diagnostics(s.docs[uri])
+	return s.notify("textDocument/publishDiagnostics", map[string]any{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// codeActions returns a quick fix for every repair in the given range, and an
+// action which applies all repairs of the document.
+func (s *server) codeActions(uri string, r jsonrepair.Range) []codeAction {
+	text, found := s.docs[uri]
+	actions := []codeAction{}
+	if !found {
+		return actions
+	}
+	fixes, diagnostics := s.diagnostics(text)
+	if len(fixes) == 0 {
+		return actions
+	}
+	for i, fix := range fixes {
+		if before(fix.Range.End, r.Start) || before(r.End, fix.Range.Start) {
+			continue
+		}
+		actions = append(actions, codeAction{
+			Title:       "Fix " + fix.Kind.String(),
+			Kind:        "quickfix",
+			Diagnostics: diagnostics[i : i+1],
+			IsPreferred: true,
+			Edit:        workspaceEdit{Changes: map[string][]jsonrepair.TextEdit{uri: fix.Edits}},
+		})
+	}
+	edits, err := jsonrepair.TextEdits(text, s.encoding)
+	if err != nil {
+		return actions
+	}
+	return append(actions, codeAction{
+		Title:       "Fix all JSON issues",
+		Kind:        "source.fixAll",
+		Diagnostics: diagnostics,
+		Edit:        workspaceEdit{Changes: map[string][]jsonrepair.TextEdit{uri: edits}},
+	})
+}
+
+func before(a, b jsonrepair.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// read reads a message with a Content-Length header.
+func (s *server) read() (*message, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	msg := &message{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, &rpcError{code: codeParseError, err: fmt.Errorf("invalid message: %w", err)}
+	}
+	return msg, nil
+}
+
+func (s *server) respond(id json.RawMessage, result any) error {
+	return s.write(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *server) notify(method string, params any) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *server) write(v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func frame(t *testing.T, msgs ...any) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	for _, msg := range msgs {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	return buf
+}
+
+// readAll reads all framed messages from buf.
+func readAll(t *testing.T, buf *bytes.Buffer) []map[string]json.RawMessage {
+	var msgs []map[string]json.RawMessage
+	r := bufio.NewReader(buf)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if errors.Is(err, io.EOF) {
+			return msgs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			t.Fatal(err)
+		}
+		var msg map[string]json.RawMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestServer(t *testing.T) {
+	uri := "file:///config.json"
+	in := frame(t,
+		map[string]any{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "text": "{\n  'a': 1 // one\n  \"b\": 2,\n}"},
+		}},
+		map[string]any{"jsonrpc": "2.0", "id": 2, "method": "textDocument/codeAction", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"range":        map[string]any{"start": map[string]int{"line": 2, "character": 0}, "end": map[string]int{"line": 2, "character": 9}},
+		}},
+		map[string]any{"jsonrpc": "2.0", "id": 3, "method": "shutdown"},
+		map[string]any{"jsonrpc": "2.0", "method": "exit"},
+	)
+	out := &bytes.Buffer{}
+	if err := newServer(in, out).run(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := readAll(t, out)
+	if len(msgs) != 4 {
+		t.Fatalf("expect 4 messages, got %d", len(msgs))
+	}
+
+	var diagnostics struct {
+		Diagnostics []diagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(msgs[1]["params"], &diagnostics); err != nil {
+		t.Fatal(err)
+	}
+	var codes []string
+	for _, d := range diagnostics.Diagnostics {
+		codes = append(codes, d.Code)
+	}
+	want := "[non-standard quotes missing comma comment trailing comma]"
+	if fmt.Sprint(codes) != want {
+		t.Errorf("got diagnostics: %v, expect: %s", codes, want)
+	}
+
+	var actions []codeAction
+	if err := json.Unmarshal(msgs[2]["result"], &actions); err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, a := range actions {
+		titles = append(titles, a.Title)
+	}
+	want = "[Fix trailing comma Fix all JSON issues]"
+	if fmt.Sprint(titles) != want {
+		t.Errorf("got code actions: %v, expect: %s", titles, want)
+	}
+	if len(actions) == 2 && len(actions[1].Edit.Changes[uri]) != 5 {
+		t.Errorf("expect 5 edits to fix all, got: %v", actions[1].Edit.Changes[uri])
+	}
+}
+
+func TestServerInvalidParams(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	in := frame(t,
+		map[string]any{"jsonrpc": "2.0", "method": "textDocument/didChange", "params": map[string]any{
+			"textDocument":   map[string]any{"uri": "file:///a.json"},
+			"contentChanges": []any{map[string]any{"text": 1}},
+		}},
+		map[string]any{"jsonrpc": "2.0", "id": 1, "method": "textDocument/codeAction", "params": map[string]any{
+			"range": "all",
+		}},
+	)
+	in.WriteString("Content-Length: 1\r\n\r\n{")
+	in.Write(frame(t, map[string]any{"jsonrpc": "2.0", "id": 2, "method": "shutdown"}).Bytes())
+	out := &bytes.Buffer{}
+	if err := newServer(in, out).run(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := readAll(t, out)
+	if len(msgs) != 2 {
+		t.Fatalf("expect 2 messages, got %d", len(msgs))
+	}
+	var resErr responseError
+	if err := json.Unmarshal(msgs[0]["error"], &resErr); err != nil {
+		t.Fatal(err)
+	}
+	if string(msgs[0]["id"]) != "1" || resErr.Code != codeInvalidParams {
+		t.Errorf("expect an invalid params error for request 1, got: %s %+v", msgs[0]["id"], resErr)
+	}
+	if string(msgs[1]["id"]) != "2" || msgs[1]["error"] != nil {
+		t.Errorf("expect a response to shutdown, got: %v", msgs[1])
+	}
+}
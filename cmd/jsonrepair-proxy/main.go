@@ -0,0 +1,52 @@
+// Command jsonrepair-proxy is a reverse proxy which repairs the JSON response
+// bodies of an upstream server.
+//
+// Usage:
+//
+//	jsonrepair-proxy -upstream http://localhost:9000 [-listen :8080]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+
+	"github.com/wakenmeng/jsonrepair"
+)
+
+func main() {
+	log.SetPrefix("jsonrepair-proxy: ")
+	listen := flag.String("listen", ":8080", "address to listen on")
+	upstream := flag.String("upstream", "", "URL of the upstream server")
+	flag.Parse()
+	if *upstream == "" {
+		fmt.Fprintln(os.Stderr, "usage: jsonrepair-proxy -upstream URL [-listen ADDR]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	proxy, err := newProxy(*upstream)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("proxying %s to %s", *listen, *upstream)
+	log.Fatal(http.ListenAndServe(*listen, proxy))
+}
+
+// newProxy returns a reverse proxy to upstream which repairs JSON responses.
+func newProxy(upstream string) (*httputil.ReverseProxy, error) {
+	target, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL %q", upstream)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ModifyResponse = jsonrepair.ModifyResponse()
+	return proxy, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"value": NaN, "items": [1, 2,],}`)
+	}))
+	defer upstream.Close()
+
+	proxy, err := newProxy(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); body != `{"value": "NaN", "items": [1, 2]}` {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	for _, upstream := range []string{"", "localhost:9000", "://"} {
+		if _, err := newProxy(upstream); err == nil {
+			t.Errorf("upstream %q: expected an error", upstream)
+		}
+	}
+}
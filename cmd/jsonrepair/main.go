@@ -0,0 +1,129 @@
+// Command jsonrepair repairs a JSON document read from a file or stdin and
+// writes the repaired document to stdout.
+//
+// Usage:
+//
+//	jsonrepair [-diff] [-comments] [file]
+//	jsonrepair -check [-comments] [file ...]
+//
+// With -diff a unified diff between the original and the repaired document
+// is written instead, annotated with the kinds of the applied repairs. With
+// -comments the comments of the document are kept, so the output is JSONC.
+//
+// With -check the documents are not written. Instead, every repair a document
+// relies on is listed as file:line:column: kind, and the exit status is 1
+// when any document needs a repair or cannot be repaired.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wakenmeng/jsonrepair"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("jsonrepair", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	diff := flags.Bool("diff", false, "write a unified diff of the repairs instead of the repaired document")
+	comments := flags.Bool("comments", false, "keep comments, writing JSONC")
+	check := flags.Bool("check", false, "list the repairs the documents rely on instead of repairing them")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jsonrepair [-diff] [-comments] [file]")
+		fmt.Fprintln(stderr, "       jsonrepair -check [-comments] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	var opts []jsonrepair.Option
+	if *comments {
+		opts = append(opts, jsonrepair.WithComments())
+	}
+	if *check {
+		return runCheck(flags.Args(), stdin, stdout, stderr, opts)
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	input := stdin
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(stderr, "jsonrepair:", err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+	text, err := io.ReadAll(input)
+	if err != nil {
+		fmt.Fprintln(stderr, "jsonrepair:", err)
+		return 1
+	}
+
+	res, err := jsonrepair.Repair(string(text), opts...)
+	if err != nil {
+		fmt.Fprintln(stderr, "jsonrepair:", err)
+		return 1
+	}
+	if *diff {
+		io.WriteString(stdout, jsonrepair.Diff(string(text), res.Output, res.Repairs...))
+		return 0
+	}
+	io.WriteString(stdout, res.Output)
+	return 0
+}
+
+// runCheck lists the repairs of the documents in the files, or in stdin
+// without files. It returns 1 when any document needs a repair.
+func runCheck(files []string, stdin io.Reader, stdout, stderr io.Writer, opts []jsonrepair.Option) int {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	code := 0
+	for _, file := range files {
+		name, text, err := readFile(file, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, "jsonrepair:", err)
+			code = 1
+			continue
+		}
+		res, err := jsonrepair.Repair(text, opts...)
+		if err != nil {
+			pos := -1
+			var repairErr jsonrepair.JSONRepairError
+			if errors.As(err, &repairErr) {
+				pos = repairErr.Position
+			}
+			fmt.Fprintln(stderr, "jsonrepair:", jsonrepair.NewFileError(name, text, pos, err))
+			code = 1
+			continue
+		}
+		for _, r := range res.Repairs {
+			p := jsonrepair.OffsetPosition(text, r.Start, jsonrepair.PositionEncodingUTF8)
+			fmt.Fprintf(stdout, "%s:%d:%d: %s\n", name, p.Line+1, p.Character+1, r.Kind)
+			code = 1
+		}
+	}
+	return code
+}
+
+// readFile returns the name and the content of the file, or of stdin for "-".
+func readFile(file string, stdin io.Reader) (string, string, error) {
+	if file == "-" {
+		text, err := io.ReadAll(stdin)
+		return "<stdin>", string(text), err
+	}
+	text, err := os.ReadFile(file)
+	return file, string(text), err
+}
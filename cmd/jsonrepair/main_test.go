@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "in.json")
+	if err := os.WriteFile(file, []byte("[1,2,]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(config, []byte("{\n  \"a\": 1,\n  b: 2,\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	valid := filepath.Join(t.TempDir(), "valid.json")
+	if err := os.WriteFile(valid, []byte(`{"a":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := []struct {
+		Args   []string
+		Stdin  string
+		Code   int
+		Stdout string
+	}{
+		{nil, `{a:1}`, 0, `{"a":1}`},
+		{[]string{file}, "", 0, "[1,2]\n"},
+		{[]string{"-diff", file}, "", 0, "--- original\n+++ repaired\n@@ -1 +1 @@ trailing comma\n-[1,2,]\n+[1,2]\n"},
+		{[]string{"--diff"}, `{"a":1}`, 0, ""},
+		{[]string{"-comments"}, "{a:1, // b\n}", 0, "{\"a\":1 // b\n}"},
+		{nil, `{:2}`, 1, ""},
+		{[]string{filepath.Join(t.TempDir(), "missing.json")}, "", 1, ""},
+		{[]string{"a", "b"}, "", 2, ""},
+		{[]string{"-check", valid}, "", 0, ""},
+		{[]string{"-check", valid, config}, "", 1, config + ":3:3: unquoted key\n" + config + ":3:7: trailing comma\n"},
+		{[]string{"-check"}, "// a\n[1]", 1, "<stdin>:1:1: comment\n"},
+		{[]string{"-check", "-comments"}, "// a\n[1]", 0, ""},
+		{[]string{"-check"}, "[1]\n{:}", 1, ""},
+	}
+
+	for _, tt := range ts {
+		var stdout, stderr bytes.Buffer
+		code := run(tt.Args, strings.NewReader(tt.Stdin), &stdout, &stderr)
+		if code != tt.Code || stdout.String() != tt.Stdout {
+			t.Errorf("args: %q, stdin: %q, expected %d %q, got %d %q (stderr %q)", tt.Args, tt.Stdin, tt.Code, tt.Stdout, code, stdout.String(), stderr.String())
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// CompletionSuffix returns the text which has to be appended to a truncated
+// JSON document to make it valid, for example `"}]` for `[{"a":"b`. The
+// suffix is the part of the repaired text after the text, so ok is false when
+// the repair changes the text itself, even if some suffix would make it
+// valid: for example when the text contains a trailing comma, comments or
+// single quoted strings, or ends in a cut off keyword like `[tru`, which is
+// repaired into the string "tru".
+func CompletionSuffix(text string) (suffix string, ok bool) {
+	prefixes := []string{text}
+	if trimmed := strings.TrimRight(text, " \t\n\r"); trimmed != text {
+		// closing brackets are inserted before trailing whitespace
+		prefixes = append(prefixes, trimmed)
+	}
+	for _, prefix := range prefixes {
+		repaired, err := JSONRepair(prefix)
+		if err != nil || !strings.HasPrefix(repaired, prefix) {
+			continue
+		}
+		suffix = repaired[len(prefix):]
+		if json.Valid([]byte(text + suffix)) {
+			return suffix, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,33 @@
+package jsonrepair
+
+import (
+	"testing"
+)
+
+func TestCompletionSuffix(t *testing.T) {
+	ts := []struct {
+		Input  string
+		Suffix string
+		OK     bool
+	}{
+		{`{"a":1}`, ``, true},
+		{`[{"a":"b`, `"}]`, true},
+		{`{"a":1 `, `}`, true},
+		{`"abc  `, `"`, true},
+		{`{"a`, `":null}`, true},
+		{`{"a":`, `null}`, true},
+		{`[1,2.`, `0]`, true},
+		{`[1,2,`, ``, false},
+		{`[tru`, ``, false},
+		{`{'a':1`, ``, false},
+		{`{"a":1 /* comment`, ``, false},
+		{``, ``, false},
+	}
+
+	for _, tt := range ts {
+		suffix, ok := CompletionSuffix(tt.Input)
+		if suffix != tt.Suffix || ok != tt.OK {
+			t.Errorf("case: %s, got: %q %v, expect: %q %v", tt.Input, suffix, ok, tt.Suffix, tt.OK)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package jsonrepair
+
+// repairWeights are the default weights of the kinds of repairs, see
+// RepairKind.Weight.
+var repairWeights = map[RepairKind]float64{
+	RepairMissingComma:            0.02,
+	RepairTrailingComma:           0,
+	RepairMissingColon:            0.05,
+	RepairMissingValue:            0.2,
+	RepairMissingClosingBrace:     0.05,
+	RepairMissingClosingBracket:   0.05,
+	RepairRedundantClosingBracket: 0.1,
+	RepairUnquotedString:          0.3,
+	RepairMissingStartQuote:       0.3,
+	RepairMissingEndQuote:         0.2,
+	RepairQuotes:                  0.01,
+	RepairEscapedString:           0.05,
+	RepairUnescapedQuote:          0.2,
+	RepairControlCharacter:        0.01,
+	RepairInvalidEscape:           0.05,
+	RepairSpecialWhitespace:       0,
+	RepairComment:                 0,
+	RepairConcatenatedString:      0.01,
+	RepairLeadingZero:             0.05,
+	RepairTruncatedNumber:         0.2,
+	RepairPythonKeyword:           0.01,
+	RepairUndefined:               0.05,
+	RepairFunctionCall:            0.05,
+	RepairNewlineDelimited:        0,
+	RepairSkippedText:             0.3,
+	RepairUnquotedKey:             0.02,
+}
+
+// Weight returns the risk of a repair of this kind changing the meaning of
+// the text, from 0 for harmless repairs like a trailing comma, up to 1. A
+// string which had to be guessed, like an unquoted string, is risky.
+func (k RepairKind) Weight() float64 {
+	return repairWeights[k]
+}
+
+// WithRepairWeights replaces the weights of the given kinds of repairs in the
+// computation of Result.Confidence, see RepairKind.Weight.
+func WithRepairWeights(weights map[RepairKind]float64) Option {
+	return func(o *options) {
+		if o.weights == nil {
+			o.weights = map[RepairKind]float64{}
+		}
+		for kind, weight := range weights {
+			o.weights[kind] = weight
+		}
+	}
+}
+
+// confidence returns the confidence in the output after the given repairs,
+// which is the product of one minus the weight of every repair.
+func (t *RepairText) confidence(repairs []RepairAction) float64 {
+	confidence := 1.0
+	for _, r := range repairs {
+		weight, ok := t.opts.weights[r.Kind]
+		if !ok {
+			weight = r.Kind.Weight()
+		}
+		switch {
+		case weight < 0:
+			weight = 0
+		case weight > 1:
+			weight = 1
+		}
+		confidence *= 1 - weight
+	}
+	return confidence
+}
@@ -0,0 +1,39 @@
+package jsonrepair
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConfidence(t *testing.T) {
+	ts := []struct {
+		Input      string
+		Opts       []Option
+		Confidence float64
+	}{
+		{`{"a":1}`, nil, 1},
+		{`[1,2,]`, nil, 1},
+		{`{"a":1, "b":[2`, nil, 0.95 * 0.95},
+		{`hello world`, nil, 0.7},
+		{`{a:1}`, nil, 0.98},
+		{`[1, abc]`, []Option{WithRepairWeights(map[RepairKind]float64{RepairUnquotedString: 0.5})}, 0.5},
+		{`[1,2,]`, []Option{WithRepairWeights(map[RepairKind]float64{RepairTrailingComma: 2})}, 0},
+	}
+	for _, tt := range ts {
+		res, err := Repair(tt.Input, tt.Opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.Input, err)
+		}
+		if math.Abs(res.Confidence-tt.Confidence) > 1e-9 {
+			t.Errorf("%s: expected confidence %v, got %v (%v)", tt.Input, tt.Confidence, res.Confidence, res.Repairs)
+		}
+	}
+}
+
+func TestRepairWeights(t *testing.T) {
+	for kind := range repairKindNames {
+		if _, ok := repairWeights[kind]; !ok {
+			t.Errorf("no weight for %s", kind)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// Decoder reads and repairs JSON values from an input stream, like
+// json.Decoder. Each value is repaired on its own as soon as it is complete,
+// so a stream of values is read one value at a time. At the end of the
+// stream, an incomplete value is repaired like a truncated text.
+type Decoder struct {
+	r    io.Reader
+	buf  []byte
+	opts []Option
+	// readErr is the error returned by r, err is the error which stops the
+	// decoder
+	readErr error
+	err     error
+	// repaired is the length of buf when it was last repaired without a
+	// final value, and scan the nesting of the value at the start of buf
+	repaired int
+	scan     scanState
+
+	useNumber             bool
+	disallowUnknownFields bool
+
+	// value decodes the value which is being read with Token, depth is the
+	// nesting depth of Token within that value
+	value *json.Decoder
+	depth int
+	// next is a value which has been read ahead by More
+	next []byte
+}
+
+// NewDecoder returns a decoder which reads from r, and repairs the values
+// using opts. A value which is cut off by the end of the input is always
+// completed, and comments are removed.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	return &Decoder{r: r, opts: opts}
+}
+
+// UseNumber causes the Decoder to unmarshal a number into an any as a
+// json.Number instead of as a float64.
+func (d *Decoder) UseNumber() { d.useNumber = true }
+
+// DisallowUnknownFields causes the Decoder to return an error when the
+// destination is a struct and the input contains object keys which do not
+// match any non-ignored, exported fields in the destination.
+func (d *Decoder) DisallowUnknownFields() { d.disallowUnknownFields = true }
+
+// Decode reads the next value from its input, repairs it and stores it in
+// the value pointed to by v. See json.Unmarshal.
+func (d *Decoder) Decode(v any) error {
+	if d.depth > 0 {
+		return d.value.Decode(v)
+	}
+	data, err := d.readValue()
+	if err != nil {
+		return err
+	}
+	return d.jsonDecoder(data).Decode(v)
+}
+
+// More reports whether there is another element in the current array or
+// object being parsed with Token, or another value in the input.
+func (d *Decoder) More() bool {
+	if d.depth > 0 {
+		return d.value.More()
+	}
+	if d.next == nil {
+		data, err := d.readValue()
+		if err != nil {
+			return err != io.EOF
+		}
+		d.next = data
+	}
+	return true
+}
+
+// Token returns the next JSON token of the repaired input, see
+// json.Decoder.Token.
+func (d *Decoder) Token() (json.Token, error) {
+	if d.depth == 0 {
+		data, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+		d.value = d.jsonDecoder(data)
+	}
+	tok, err := d.value.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'), json.Delim('['):
+		d.depth++
+	case json.Delim('}'), json.Delim(']'):
+		d.depth--
+	}
+	return tok, nil
+}
+
+func (d *Decoder) jsonDecoder(data []byte) *json.Decoder {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if d.useNumber {
+		dec.UseNumber()
+	}
+	if d.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	return dec
+}
+
+// readValue returns the next repaired value of the input. It reads until the
+// value cannot change anymore by what follows in the input.
+func (d *Decoder) readValue() ([]byte, error) {
+	if d.next != nil {
+		data := d.next
+		d.next = nil
+		return data, nil
+	}
+	for d.err == nil {
+		if d.readErr == nil && len(d.buf) < 2*d.repaired && d.scan.open(d.buf) {
+			// the value cannot be final yet, it is repaired again once the
+			// buffer has doubled
+			d.fill()
+			continue
+		}
+		text := string(d.buf)
+		if isBlank(text) {
+			if d.readErr != nil {
+				d.err = d.readErr
+				break
+			}
+			d.fill()
+			continue
+		}
+		start := time.Now()
+		t := newRepairText(text, d.opts)
+		t.opts.firstValue = true
+		t.opts.truncation = TruncationComplete
+		t.opts.comments = false
+		err := t.repair()
+		switch {
+		case err != nil && (!t.endSeen || d.readErr == io.EOF):
+			t.observe(len(d.buf), start, err)
+			d.err = err
+		case err == nil && (!t.valueEndSeen || d.readErr == io.EOF):
+			// the value is final, or the input ended
+			n := byteOffset(d.buf, t.i)
+			err = t.finish()
+			t.observe(n, start, err)
+			if err != nil {
+				d.err = err
+				break
+			}
+			d.buf = d.buf[n:]
+			d.repaired, d.scan = 0, scanState{}
+			return []byte(t.output.String()), nil
+		case d.readErr != nil:
+			d.err = d.readErr
+		default:
+			d.repaired = len(d.buf)
+			d.fill()
+		}
+	}
+	return nil, d.err
+}
+
+// fill reads more of the input into the buffer, which is grown in
+// proportion to its size.
+func (d *Decoder) fill() {
+	if cap(d.buf)-len(d.buf) < 4096 {
+		buf := make([]byte, len(d.buf), 2*cap(d.buf)+4096)
+		copy(buf, d.buf)
+		d.buf = buf
+	}
+	n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err != nil {
+		d.readErr = err
+	}
+}
+
+// scanState tracks the brackets and double quoted strings of a value, to
+// tell cheaply that the value is still open without repairing it.
+type scanState struct {
+	n, depth         int
+	inString, escape bool
+	// closed is set once the value has no open bracket anymore
+	closed bool
+}
+
+// open scans the bytes of buf after the previously scanned ones, and reports
+// whether the value at the start of buf is certainly incomplete. Brackets in
+// comments or single quoted strings may be miscounted, which only delays the
+// repair until the buffer has doubled.
+func (s *scanState) open(buf []byte) bool {
+	for ; s.n < len(buf) && !s.closed; s.n++ {
+		c := buf[s.n]
+		switch {
+		case s.escape:
+			s.escape = false
+		case s.inString:
+			s.escape = c == '\\'
+			s.inString = c != '"'
+		case c == '"':
+			s.inString = true
+		case c == '{' || c == '[':
+			s.depth++
+		case c == '}' || c == ']':
+			s.depth--
+			s.closed = s.depth <= 0
+		}
+	}
+	return !s.closed && (s.depth > 0 || s.inString)
+}
+
+// byteOffset returns the offset in b of the rune at the given position.
+func byteOffset(b []byte, pos int) int {
+	offset := 0
+	for ; pos > 0 && offset < len(b); pos-- {
+		_, size := utf8.DecodeRune(b[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// isBlank returns whether the text holds only whitespace and comments.
+func isBlank(text string) bool {
+	t := newRepairText(text, nil)
+	t.parseWhitespaceAndSkipComments()
+	return t.i >= len(t.text)
+}
@@ -0,0 +1,176 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoder(t *testing.T) {
+	input := "{\"a\":1} {b:2}\n[1,2,]\n'x' 12 /* c */ \"foo\" tru"
+	expected := []any{
+		map[string]any{"a": 1.0},
+		map[string]any{"b": 2.0},
+		[]any{1.0, 2.0},
+		"x",
+		12.0,
+		"foo",
+		"tru",
+	}
+
+	for name, r := range map[string]io.Reader{
+		"reader":   strings.NewReader(input),
+		"one byte": iotest.OneByteReader(strings.NewReader(input)),
+	} {
+		dec := NewDecoder(r)
+		var values []any
+		for {
+			var v any
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			values = append(values, v)
+		}
+		if !reflect.DeepEqual(values, expected) {
+			t.Errorf("%s: expected %v, got %v", name, expected, values)
+		}
+	}
+}
+
+// chunkReader returns one chunk per read, and then fails.
+type chunkReader struct {
+	chunks []string
+}
+
+var errNoMoreChunks = errors.New("no more chunks")
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, errNoMoreChunks
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestDecoderStream(t *testing.T) {
+	// a complete value is returned without waiting for more input
+	r := &chunkReader{chunks: []string{"{\"a\":", "1}\n", "12", "34 "}}
+	dec := NewDecoder(r)
+	var v any
+	if err := dec.Decode(&v); err != nil || !reflect.DeepEqual(v, map[string]any{"a": 1.0}) {
+		t.Fatalf("unexpected value %v, err %v", v, err)
+	}
+	if len(r.chunks) != 2 {
+		t.Errorf("expected 2 unread chunks, got %d", len(r.chunks))
+	}
+	// a number may continue in the next chunk
+	if err := dec.Decode(&v); err != nil || v != 1234.0 {
+		t.Fatalf("unexpected value %v, err %v", v, err)
+	}
+	if err := dec.Decode(&v); err != errNoMoreChunks {
+		t.Errorf("expected read error, got %v", err)
+	}
+}
+
+func TestDecoderToken(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[1 {"a":2,} 'x'] null`))
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('[') {
+		t.Fatalf("unexpected token %v, err %v", tok, err)
+	}
+	var values []any
+	for dec.More() {
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, v)
+	}
+	if expected := []any{1.0, map[string]any{"a": 2.0}, "x"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+	var tokens []json.Token
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, tok)
+	}
+	if expected := []json.Token{json.Delim(']'), nil}; !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected %v, got %v", expected, tokens)
+	}
+	if dec.More() {
+		t.Errorf("expected no more values")
+	}
+}
+
+func TestDecoderOptions(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{a: 12345678901234567890}`))
+	dec.UseNumber()
+	var v map[string]any
+	if err := dec.Decode(&v); err != nil || v["a"] != json.Number("12345678901234567890") {
+		t.Errorf("unexpected value %v, err %v", v, err)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{a: 1, b: 2}`))
+	dec.DisallowUnknownFields()
+	var s struct{ A int }
+	if err := dec.Decode(&s); err == nil {
+		t.Errorf("expected an error for unknown field")
+	}
+
+	dec = NewDecoder(strings.NewReader(`{:}`))
+	if err := dec.Decode(&v); err == nil || err.Error() != "Object key expected at position 1" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDecoderSmallReads(t *testing.T) {
+	// the value is not repaired again after every read
+	text := "[" + strings.Repeat(`{"a":1,"b":"x]}"},`, 5000) + "]"
+	var chunks []io.Reader
+	for i := 0; i < len(text); i += 1000 {
+		chunks = append(chunks, strings.NewReader(text[i:min(i+1000, len(text))]))
+	}
+	r := io.MultiReader(chunks...)
+	var v []any
+	if err := NewDecoder(r).Decode(&v); err != nil || len(v) != 5000 {
+		t.Fatalf("unexpected value of length %d, err %v", len(v), err)
+	}
+}
+
+func TestScanState(t *testing.T) {
+	ts := []struct {
+		Input string
+		Open  bool
+	}{
+		{`1`, false},
+		{`"a`, true},
+		{`"a\"`, true},
+		{`"a"`, false},
+		{`{"a":[1`, true},
+		{`{"a":"}"`, true},
+		{`{"a":1}`, false},
+		{`{"a":1} [`, false},
+		{`[1}`, false},
+	}
+	for _, tt := range ts {
+		var s scanState
+		if open := s.open([]byte(tt.Input)); open != tt.Open {
+			t.Errorf("%s: expected open %v, got %v", tt.Input, tt.Open, open)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package jsonrepair
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Diff renders a unified diff between the original text and the repaired
+// output. Each hunk is annotated with the kinds of the given repairs which
+// have been applied to the original lines of the hunk, for example
+// `@@ -1,3 +1,3 @@ trailing comma, comment`. The diff is empty when both are
+// equal.
+func Diff(original, repaired string, repairs ...RepairAction) string {
+	a := splitLines(original)
+	b := splitLines(repaired)
+	ops := diffLines(a, b)
+	lineStarts := lineStartOffsets(original)
+
+	var sb strings.Builder
+	for start := 0; start < len(ops); {
+		// find the next change and the end of its hunk
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		end := start
+		for unchanged := 0; end < len(ops) && unchanged <= 2*diffContext; end++ {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > start && ops[end-1].kind == ' ' {
+			end--
+		}
+		from := max0(start - diffContext)
+		to := min(end+diffContext, len(ops))
+
+		lineA, lineB := 0, 0
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				lineA++
+			}
+			if op.kind != '-' {
+				lineB++
+			}
+		}
+		countA, countB := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+
+		if sb.Len() == 0 {
+			sb.WriteString("--- original\n+++ repaired\n")
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@", hunkRange(lineA, countA), hunkRange(lineB, countB))
+		if kinds := repairKindsInLines(repairs, lineStarts, lineA, lineA+countA); kinds != "" {
+			sb.WriteString(" " + kinds)
+		}
+		sb.WriteByte('\n')
+		for _, op := range ops[from:to] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return sb.String()
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line+1)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
+
+// repairKindsInLines returns the distinct kinds of the repairs starting in
+// the lines [from, to) of the original text.
+func repairKindsInLines(repairs []RepairAction, lineStarts []int, from, to int) string {
+	var kinds []string
+	seen := map[RepairKind]bool{}
+	for _, r := range repairs {
+		line := 0
+		for line+1 < len(lineStarts) && lineStarts[line+1] <= r.Start {
+			line++
+		}
+		if line >= from && (line < to || (from == to && line == from)) && !seen[r.Kind] {
+			seen[r.Kind] = true
+			kinds = append(kinds, r.Kind.String())
+		}
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// lineStartOffsets returns the rune offset of the start of every line.
+func lineStartOffsets(text string) []int {
+	starts := []int{0}
+	offset := 0
+	for _, r := range text {
+		offset++
+		if r == '\n' {
+			starts = append(starts, offset)
+		}
+	}
+	return starts
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, using the
+// Myers diff algorithm.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int{}, v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace, offset, d, k)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrackDiff(a, b []string, trace [][]int, offset, d, k int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for ; d > 0; d-- {
+		v := trace[d]
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+		k = prevK
+	}
+	for x > 0 {
+		x--
+		ops = append(ops, diffOp{' ', a[x]})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func max0(a int) int {
+	if a < 0 {
+		return 0
+	}
+	return a
+}
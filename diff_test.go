@@ -0,0 +1,58 @@
+package jsonrepair
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	ts := []struct {
+		Input  string
+		Expect string
+	}{
+		{"{\"a\":1}\n", ""},
+		{"{'a': None}\n", "--- original\n+++ repaired\n@@ -1 +1 @@ non-standard quotes, python keyword\n-{'a': None}\n+{\"a\": null}\n"},
+		{"[1,2", "--- original\n+++ repaired\n@@ -1 +1 @@ missing closing bracket\n-[1,2\n\\ No newline at end of file\n+[1,2]\n\\ No newline at end of file\n"},
+		{
+			"{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3,\n  \"d\": 4,\n  \"e\": 5,\n  \"f\": 6,\n  \"g\": 7,\n  \"h\": [1,2,],\n}\n",
+			"--- original\n+++ repaired\n@@ -6,5 +6,5 @@ trailing comma\n   \"e\": 5,\n   \"f\": 6,\n   \"g\": 7,\n-  \"h\": [1,2,],\n+  \"h\": [1,2]\n }\n",
+		},
+		{
+			"[\n1,\n2,\n3,\n4,\n5,\n6,\n7,\n8,\n9,\n10,\n11,\n12 13\n]",
+			"--- original\n+++ repaired\n@@ -10,5 +10,5 @@ missing comma\n 9,\n 10,\n 11,\n-12 13\n+12, 13\n ]\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range ts {
+		res, err := Repair(tt.Input)
+		if err != nil {
+			t.Errorf("input: %q, err: %v", tt.Input, err)
+			continue
+		}
+		if got := Diff(tt.Input, res.Output, res.Repairs...); got != tt.Expect {
+			t.Errorf("input: %q\nexpected:\n%s\ngot:\n%s", tt.Input, tt.Expect, got)
+		}
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := splitLines("a\nb\nc\nd\n")
+	b := splitLines("a\nc\nx\nd\ne\n")
+	ops := diffLines(a, b)
+	var kinds string
+	for _, op := range ops {
+		kinds += string(op.kind)
+	}
+	if kinds != " - + +" {
+		t.Errorf("unexpected edit script %q", kinds)
+	}
+	var gotA, gotB []string
+	for _, op := range ops {
+		if op.kind != '+' {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.line)
+		}
+	}
+	if len(gotA) != len(a) || len(gotB) != len(b) {
+		t.Errorf("edit script does not reproduce both sides: %v", ops)
+	}
+}
@@ -0,0 +1,203 @@
+package jsonrepair
+
+import (
+	"unicode/utf8"
+)
+
+// PositionEncoding is the unit in which Position.Character counts, like the
+// position encodings of the Language Server Protocol.
+type PositionEncoding int
+
+const (
+	// PositionEncodingUTF16 counts UTF-16 code units, the default of the
+	// Language Server Protocol.
+	PositionEncodingUTF16 PositionEncoding = iota
+	// PositionEncodingUTF8 counts bytes.
+	PositionEncodingUTF8
+)
+
+type (
+	// Position is a zero-based line and character offset in a text.
+	Position struct {
+		Line      int `json:"line"`
+		Character int `json:"character"`
+	}
+
+	// Range is the part of a text between Start and End, End exclusive.
+	Range struct {
+		Start Position `json:"start"`
+		End   Position `json:"end"`
+	}
+
+	// TextEdit replaces the text in Range by NewText.
+	TextEdit struct {
+		Range   Range  `json:"range"`
+		NewText string `json:"newText"`
+	}
+
+	// runeEdit replaces the runes [start, end) of a text by newText.
+	runeEdit struct {
+		start   int
+		end     int
+		newText []rune
+	}
+)
+
+// TextEdits repairs the given text, and returns the repair as a list of edits
+// against the text instead of a repaired document. Text which does not need
+// a repair is left untouched. The edits are sorted and do not overlap.
+func TextEdits(text string, encoding PositionEncoding, opts ...Option) ([]TextEdit, error) {
+	_, positions, edits, err := repairEdits(text, encoding, opts)
+	if err != nil {
+		return nil, err
+	}
+	textEdits := []TextEdit{}
+	for _, e := range edits {
+		textEdits = append(textEdits, e.textEdit(positions))
+	}
+	return textEdits, nil
+}
+
+// Fix is a single repair together with the edits which apply it.
+type Fix struct {
+	Kind  RepairKind
+	Range Range
+	Edits []TextEdit
+}
+
+// Fixes repairs the given text, and returns every applied repair with the
+// edits against the text which apply it. Repairs next to each other may share
+// an edit.
+func Fixes(text string, encoding PositionEncoding, opts ...Option) ([]Fix, error) {
+	res, positions, edits, err := repairEdits(text, encoding, opts)
+	if err != nil {
+		return nil, err
+	}
+	fixes := []Fix{}
+	for _, action := range res.Repairs {
+		fix := Fix{
+			Kind:  action.Kind,
+			Range: Range{Start: positions[action.Start], End: positions[action.End]},
+			Edits: []TextEdit{},
+		}
+		for _, e := range edits {
+			if e.start <= action.End && action.Start <= e.end {
+				fix.Edits = append(fix.Edits, e.textEdit(positions))
+			}
+		}
+		fixes = append(fixes, fix)
+	}
+	return fixes, nil
+}
+
+// repairEdits repairs the text, and returns the result, the positions of the
+// runes of the text and the edits which turn the text into the output.
+func repairEdits(text string, encoding PositionEncoding, opts []Option) (*Result, []Position, []runeEdit, error) {
+	opts = append(opts[:len(opts):len(opts)], WithSourceMap())
+	res, err := Repair(text, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	runes := []rune(text)
+	output := []rune(res.Output)
+	edits := runeEdits(runes, output, sourceOf(res.SourceMap, len(output)))
+	return res, newPositions(runes, encoding), edits, nil
+}
+
+func (e runeEdit) textEdit(positions []Position) TextEdit {
+	return TextEdit{
+		Range:   Range{Start: positions[e.start], End: positions[e.end]},
+		NewText: string(e.newText),
+	}
+}
+
+// sourceOf returns the origin of every output rune covered by m.
+func sourceOf(m *SourceMap, length int) []int {
+	source := make([]int, length)
+	for i := range source {
+		source[i] = -1
+	}
+	for _, segment := range m.Segments {
+		for i := 0; i < segment.Length; i++ {
+			source[segment.Output+i] = segment.Input + i
+		}
+	}
+	return source
+}
+
+// runeEdits returns the edits which turn text into output. Output runes which
+// originate from the same rune in the text are kept.
+func runeEdits(text, output []rune, source []int) []runeEdit {
+	var edits []runeEdit
+	var pending *runeEdit
+	in := 0 // first rune of the text which is neither kept nor replaced yet
+	edit := func() *runeEdit {
+		if pending == nil {
+			pending = &runeEdit{start: in, end: in}
+		}
+		return pending
+	}
+	flush := func() {
+		if pending != nil {
+			edits = append(edits, *pending)
+			pending = nil
+		}
+	}
+	for out, r := range output {
+		src := source[out]
+		if src >= in && text[src] == r {
+			if src > in {
+				edit().end = src
+			}
+			flush()
+			in = src + 1
+			continue
+		}
+		e := edit()
+		e.newText = append(e.newText, r)
+		if src >= in {
+			// replaced rune, like a single quote by a double quote
+			e.end = src + 1
+			in = src + 1
+		}
+	}
+	if in < len(text) {
+		edit().end = len(text)
+	}
+	flush()
+	return edits
+}
+
+// OffsetPosition returns the Position of the rune at the given offset in text,
+// like the Position of a JSONRepairError.
+func OffsetPosition(text string, offset int, encoding PositionEncoding) Position {
+	runes := []rune(text)
+	if offset < 0 {
+		offset = 0
+	}
+	runes = runes[:min(offset, len(runes))]
+	return newPositions(runes, encoding)[len(runes)]
+}
+
+// newPositions returns the Position of every rune in text, and of the end of
+// the text.
+func newPositions(text []rune, encoding PositionEncoding) []Position {
+	positions := make([]Position, len(text)+1)
+	var pos Position
+	for i, r := range text {
+		positions[i] = pos
+		switch {
+		case r == '\n' || (r == '\r' && (i+1 >= len(text) || text[i+1] != '\n')):
+			pos.Line++
+			pos.Character = 0
+		case encoding == PositionEncodingUTF8:
+			pos.Character += utf8.RuneLen(r)
+		case r >= 0x10000:
+			pos.Character += 2
+		default:
+			pos.Character++
+		}
+	}
+	positions[len(text)] = pos
+	return positions
+}
@@ -0,0 +1,111 @@
+package jsonrepair
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextEdits(t *testing.T) {
+	ts := []struct {
+		Input string
+		Want  []TextEdit
+	}{
+		{`{"a":1}`, []TextEdit{}},
+		{`{'a':1,}`, []TextEdit{
+			{Range{Position{0, 1}, Position{0, 2}}, `"`},
+			{Range{Position{0, 3}, Position{0, 4}}, `"`},
+			{Range{Position{0, 6}, Position{0, 7}}, ``},
+		}},
+		{"[1\n2 // two\n", []TextEdit{
+			{Range{Position{0, 2}, Position{0, 2}}, `,`},
+			{Range{Position{1, 1}, Position{1, 1}}, `]`},
+			{Range{Position{1, 2}, Position{1, 8}}, ``},
+		}},
+		{`["😀", a]`, []TextEdit{
+			{Range{Position{0, 7}, Position{0, 7}}, `"`},
+			{Range{Position{0, 8}, Position{0, 8}}, `"`},
+		}},
+	}
+
+	for _, tt := range ts {
+		edits, err := TextEdits(tt.Input, PositionEncodingUTF16)
+		if err != nil {
+			t.Errorf("case: %s, err: %v", tt.Input, err)
+			continue
+		}
+		if len(edits) != len(tt.Want) {
+			t.Errorf("case: %s, got: %v, expect: %v", tt.Input, edits, tt.Want)
+			continue
+		}
+		for i := range edits {
+			if edits[i] != tt.Want[i] {
+				t.Errorf("case: %s, got: %v, expect: %v", tt.Input, edits, tt.Want)
+				break
+			}
+		}
+	}
+}
+
+func TestTextEditsApply(t *testing.T) {
+	inputs := []string{
+		`{a:'b', c: [1 2 3,], d: "x" + "y", e: NumberLong("2"), f: 007, g: undefined}`,
+		"/* 1 */\n{}\n/* 2 */\n{},\n",
+		"{\"a\":\"b★c\\\\d\\\"e\n\tf",
+		"[\"😀\", 'é', a]\r\n// end",
+	}
+	for _, input := range inputs {
+		want, err := JSONRepair(input)
+		if err != nil {
+			t.Errorf("case: %s, err: %v", input, err)
+			continue
+		}
+		edits, err := TextEdits(input, PositionEncodingUTF8)
+		if err != nil {
+			t.Errorf("case: %s, err: %v", input, err)
+			continue
+		}
+		if got := applyEdits(input, edits); got != want {
+			t.Errorf("case: %s, got: %s, expect: %s", input, got, want)
+		}
+	}
+}
+
+// applyEdits applies edits with UTF-8 positions to text.
+func applyEdits(text string, edits []TextEdit) string {
+	lines := strings.SplitAfter(text, "\n")
+	offset := func(pos Position) int {
+		n := 0
+		for _, line := range lines[:pos.Line] {
+			n += len(line)
+		}
+		return n + pos.Character
+	}
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		text = text[:offset(e.Range.Start)] + e.NewText + text[offset(e.Range.End):]
+	}
+	return text
+}
+
+func TestFixes(t *testing.T) {
+	fixes, err := Fixes("{'a':1 \"b\":2,}", PositionEncodingUTF16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		Kind  RepairKind
+		Edits int
+	}{
+		{RepairQuotes, 2},
+		{RepairMissingComma, 1},
+		{RepairTrailingComma, 1},
+	}
+	if len(fixes) != len(want) {
+		t.Fatalf("got: %v, expect: %v", fixes, want)
+	}
+	for i, fix := range fixes {
+		if fix.Kind != want[i].Kind || len(fix.Edits) != want[i].Edits {
+			t.Errorf("got: %v, expect: %v", fix, want[i])
+		}
+	}
+}
@@ -25,6 +25,16 @@ type (
 		JSONRepairError
 		Got string
 	}
+
+	// TooManyRepairsError is returned when a text needs more repairs than
+	// allowed by WithMaxRepairs, or a repair forbidden by
+	// WithForbiddenRepairs. Its position is the position of the first repair
+	// exceeding the limit, or of the first forbidden repair, and Result holds
+	// the rejected repair.
+	TooManyRepairsError struct {
+		JSONRepairError
+		Result *Result
+	}
 )
 
 func NewJSONRepairError(msg string) JSONRepairError {
@@ -61,3 +71,9 @@ func InvalidUnicodeCharacter(ch string) JSONRepairError {
 	msg := fmt.Sprintf("Invalid unicode character \"%s\"", ch)
 	return NewJSONRepairError(msg)
 }
+
+// Unwrap returns the JSONRepairError, so a TooManyRepairsError is found by
+// errors.As like the other errors of the repair.
+func (e TooManyRepairsError) Unwrap() error {
+	return e.JSONRepairError
+}
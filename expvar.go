@@ -0,0 +1,52 @@
+package jsonrepair
+
+import (
+	"expvar"
+	"time"
+)
+
+// ExpvarObserver is an Observer which publishes the statistics with the
+// expvar package, as a map holding
+//
+//	calls        the number of repaired texts
+//	duration_ns  the total duration of the repairs in nanoseconds
+//	repairs      the number of repairs by kind, like "missing_comma"
+//	errors       the number of failed repairs by error kind, like
+//	             "unexpected_character"
+type ExpvarObserver struct {
+	calls    *expvar.Int
+	duration *expvar.Int
+	repairs  *expvar.Map
+	errors   *expvar.Map
+}
+
+// NewExpvarObserver returns an ExpvarObserver which publishes its map under
+// the given name. Like expvar.Publish, it panics when the name is already in
+// use.
+func NewExpvarObserver(name string) *ExpvarObserver {
+	o := &ExpvarObserver{
+		calls:    new(expvar.Int),
+		duration: new(expvar.Int),
+		repairs:  new(expvar.Map).Init(),
+		errors:   new(expvar.Map).Init(),
+	}
+	m := expvar.NewMap(name)
+	m.Set("calls", o.calls)
+	m.Set("duration_ns", o.duration)
+	m.Set("repairs", o.repairs)
+	m.Set("errors", o.errors)
+	return o
+}
+
+func (o *ExpvarObserver) ObserveRepair(kind RepairKind, count int) {
+	o.repairs.Add(snakeCase(kind.String()), int64(count))
+}
+
+func (o *ExpvarObserver) ObserveError(kind string) {
+	o.errors.Add(snakeCase(kind), 1)
+}
+
+func (o *ExpvarObserver) ObserveDuration(d time.Duration) {
+	o.calls.Add(1)
+	o.duration.Add(int64(d))
+}
@@ -3,6 +3,9 @@ package jsonrepair
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -32,12 +35,101 @@ type (
 	RepairText struct {
 		text   []rune //string
 		i      int
-		output []rune
+		output outputBuffer
+		opts   options
+
+		// path holds the segments of the JSON Pointer of the value being
+		// parsed, which is only built when needed, see pointer
+		path []pathSegment
+		// pointers holds the JSON Pointer of the first pointerDepth segments
+		// of path, and pointerEnds the length of the pointer of each shorter
+		// prefix, so that the pointers of the enclosing values share it
+		pointers     string
+		pointerEnds  []int
+		pointerDepth int
+		// truncated is set once a value has been repaired because the text
+		// ended before it was complete
+		truncated  bool
+		incomplete []incompleteElement
+		partial    []Partial
+		repairs    []RepairAction
+		// stable is the length of the output which is final, set once the
+		// parser has looked at the end of the text; -1 before that
+		stable int
+		// endSeen is set once the parser has looked at the end of the text,
+		// valueEndSeen holds endSeen from when the last root value was
+		// complete
+		endSeen      bool
+		valueEndSeen bool
+		// tokens holds the lexical tokens of the text, only recorded for
+		// Tokenize
+		tokens []Token
+		// comments holds the start and end positions of the comments which
+		// are kept in the output
+		comments [][2]int
+
+		// handler receives the events of Walk, handled counts the events and
+		// flushed the repairs passed to the handler
+		handler Handler
+		handled int
+		flushed int
+		// valueStart is the position of the key or value being parsed. When
+		// its event is passed to the handler, it ends at t.i, see ParseTree
+		valueStart int
+
+		// choices holds the alternatives to take at the ambiguous points of
+		// the text for RepairCandidates, and decisions the number of
+		// alternatives at every ambiguous point met; nil otherwise
+		choices   []int
+		decisions []int
+	}
+
+	// incompleteElement is an array element or object property which was cut
+	// off by the end of the text
+	incompleteElement struct {
+		start   int // position in the text where the element starts
+		path    string
+		inArray bool
+	}
+
+	// Result holds the repaired document together with details about the
+	// repair.
+	Result struct {
+		// Output is the repaired JSON document.
+		Output string
+		// Truncated is the JSON Pointer of the incomplete trailing element
+		// which was dropped or marked according to the TruncationPolicy. It
+		// is empty when the text was not truncated or TruncationComplete is
+		// used.
+		Truncated string
+		// Partial lists the values which have been synthesized or are still
+		// open because the text ended before they were complete, innermost
+		// first. Empty when the text was not truncated.
+		Partial []Partial
+		// Stable is the number of runes at the start of Output which do not
+		// change when more text is appended to the input. Only set when
+		// WithPrefixStable is used.
+		Stable int
+		// SourceMap maps positions in Output to positions in the text. Only
+		// set when WithSourceMap is used.
+		SourceMap *SourceMap
+		// Repairs lists the repairs applied to the text, in the order of the
+		// text.
+		Repairs []RepairAction
+		// Confidence estimates how likely Output is the document which was
+		// meant, from 1 for a valid text down to 0, see RepairKind.Weight.
+		Confidence float64
+		// Rest is the text after the first top-level value, and RestOffset
+		// its byte offset in the text, so that Rest is text[RestOffset:] for
+		// valid UTF-8. Only set when WithRest is used.
+		Rest       string
+		RestOffset int
 	}
 )
 
 func (t *RepairText) CharCode(i int) rune {
 	if i >= len(t.text) {
+		t.observeEnd()
 		return -1
 	}
 	return t.text[i]
@@ -45,59 +137,304 @@ func (t *RepairText) CharCode(i int) rune {
 
 func (t *RepairText) Char(i int) string {
 	if i >= len(t.text) {
+		t.observeEnd()
 		return ""
 	}
 	return string(t.text[i])
 }
 
 func (t *RepairText) Slice(a, b int) []rune {
+	if b > len(t.text) {
+		t.observeEnd()
+	}
 	return t.text[a:min(b, len(t.text))]
 }
 
-func JSONRepair(text string) (string, error) {
-	//i := 0
+// atEnd returns whether the whole text has been consumed.
+func (t *RepairText) atEnd() bool {
+	if t.i >= len(t.text) {
+		t.observeEnd()
+		return true
+	}
+	return false
+}
+
+func JSONRepair(text string, opts ...Option) (string, error) {
+	res, err := Repair(text, opts...)
+	if err != nil {
+		return "", err
+	}
+	return res.Output, nil
+}
+
+// Repair repairs the given text like JSONRepair, and returns the repaired
+// document together with details about the applied repairs.
+func Repair(text string, opts ...Option) (*Result, error) {
+	start := time.Now()
+	t := newRepairText(text, opts)
+	res, err := t.run()
+	t.observe(len(text), start, err)
+	return res, err
+}
+
+func (t *RepairText) run() (*Result, error) {
+	if err := t.repair(); err != nil {
+		return nil, err
+	}
+	if err := t.finish(); err != nil {
+		return nil, err
+	}
+	if t.opts.truncation == TruncationComplete {
+		return t.result(), nil
+	}
+	element, found := t.lastIncompleteElement()
+	if !found {
+		return t.result(), nil
+	}
+	res := t.result()
+	if t.opts.truncation == TruncationDrop {
+		dropped := newRepairText(string(t.text[:element.start]), nil)
+		dropped.opts = t.opts
+		dropped.opts.truncation = TruncationComplete
+		if err := dropped.repair(); err != nil {
+			return nil, err
+		}
+		if dropped.opts.bestEffort {
+			dropped.quoteInvalid()
+		}
+		rest, restOffset := res.Rest, res.RestOffset
+		res = dropped.result()
+		res.Rest, res.RestOffset = rest, restOffset
+	}
+	res.Truncated = element.path
+	return res, nil
+}
+
+// finish completes a successful repair: an output which is still invalid is
+// quoted in best effort mode, and the repairs are checked against
+// WithMaxRepairs and WithForbiddenRepairs.
+func (t *RepairText) finish() error {
+	if t.opts.bestEffort {
+		t.quoteInvalid()
+	}
+	return t.checkRepairs()
+}
+
+func (t *RepairText) result() *Result {
+	res := &Result{
+		Output:  t.output.String(),
+		Partial: t.partial,
+		Repairs: t.sortedRepairs(),
+	}
+	res.Confidence = t.confidence(res.Repairs)
+	if t.opts.prefixStable {
+		res.Stable = t.stableLength()
+	}
+	if t.opts.sourceMap {
+		res.SourceMap = newSourceMap(t.output.sources())
+	}
+	if t.opts.firstValue {
+		res.Rest = string(t.text[t.i:])
+		res.RestOffset = len(string(t.text[:t.i]))
+	}
+	return res
+}
+
+// StableOutput returns the part of the output which does not change when
+// more text is appended to the input. See WithPrefixStable.
+func (r *Result) StableOutput() string {
+	return string([]rune(r.Output)[:r.Stable])
+}
 
-	t := RepairText{
+func newRepairText(text string, opts []Option) *RepairText {
+	t := &RepairText{
 		text:   []rune(text),
 		i:      0,
-		output: []rune{},
+		stable: -1,
+		opts:   options{maxRepairs: -1},
+	}
+	for _, opt := range opts {
+		opt(&t.opts)
+	}
+	t.output = newOutputBuffer()
+	if t.opts.comments {
+		t.output.comment = t.inComment
+	}
+	return t
+}
+
+func (t *RepairText) repair() error {
+	if t.opts.bestEffort {
+		t.parseWhitespaceAndSkipComments()
+		t.skipUnexpected(cannotStartValue)
 	}
 	processedValue, err := t.parseValue()
 	if err != nil {
-		return "", err
+		return err
 	}
 	if !processedValue {
-		return "", UnexpectedEndError.At(len(t.text))
+		if !t.opts.bestEffort {
+			return UnexpectedEndError.At(len(t.text))
+		}
+		t.insertNull()
+	}
+	if t.opts.firstValue {
+		return nil
 	}
 	processedComma := t.parseCharacter(codeComma)
 	if processedComma {
 		t.parseWhitespaceAndSkipComments()
 	}
-	if t.i < len(t.text) && IsStartOfValue(t.text[t.i]) && EndsWithCommaOrNewline(string(t.output)) {
+	// newline delimited JSON changes the root of the output, so it cannot be
+	// detected without changing output which has been returned before
+	if !t.opts.prefixStable && t.i < len(t.text) && IsStartOfValue(t.text[t.i]) && EndsWithCommaOrNewline(t.output.String()) {
 		if !processedComma {
-			t.output = InsertBeforeLastWhitespace(t.output, ",")
+			at := t.output.insertBeforeLastWhitespace(",")
+			t.reportInsert(RepairMissingComma, &t.output, at)
+			t.insertToken(TokenPunctuation, ",", &t.output, at)
 		}
 		t.parseNewlineDelimitedJSON()
 	} else if processedComma {
-		t.output = []rune(stripLastOccurrence(string(t.output), ",", false))
+		t.reportTrailingComma(t.output.stripLastOccurrence(codeComma, false))
 	}
 	for t.CharCode(t.i) == codeClosingBrace || t.CharCode(t.i) == codeClosingBracket {
+		t.report(RepairRedundantClosingBracket, t.i, t.i+1)
+		t.token(TokenPunctuation, t.i, t.i+1, true)
 		t.i++
 		t.parseWhitespaceAndSkipComments()
 	}
 
+	if t.atEnd() {
+		return nil
+	}
+	if t.opts.bestEffort {
+		t.skipText(t.i, len(t.text))
+		return nil
+	}
+	return UnexpectedCharacterError.MessageAppend(fmt.Sprintf(`"%s"`, string(t.text[t.i]))).At(t.i)
+}
+
+// markTruncated records that the value being parsed is incomplete when the
+// end of the text has been reached.
+func (t *RepairText) markTruncated() {
 	if t.i >= len(t.text) {
-		return string(t.output), nil
+		t.truncated = true
+	}
+}
+
+// trackElement records the array element or object property starting at
+// position start as incomplete when it caused the text to be truncated.
+// wasTruncated is the truncation state from before the element was parsed.
+func (t *RepairText) trackElement(start int, wasTruncated, inArray bool) {
+	if !wasTruncated && t.truncated {
+		t.incomplete = append(t.incomplete, incompleteElement{
+			start:   start,
+			path:    t.pointer(),
+			inArray: inArray,
+		})
+	}
+}
+
+// lastIncompleteElement returns the element to drop or mark on truncation:
+// the outermost incomplete array element, so that only complete records are
+// kept, or else the innermost incomplete object property.
+func (t *RepairText) lastIncompleteElement() (incompleteElement, bool) {
+	for i := len(t.incomplete) - 1; i >= 0; i-- {
+		if t.incomplete[i].inArray {
+			return t.incomplete[i], true
+		}
+	}
+	if len(t.incomplete) > 0 {
+		return t.incomplete[0], true
+	}
+	return incompleteElement{}, false
+}
+
+// pathSegment is an array index, or an object key which has been written to
+// the output between keyStart and keyEnd.
+type pathSegment struct {
+	index            int
+	keyStart, keyEnd int
+	isKey            bool
+}
+
+// pushPath adds a segment to the path of the value being parsed.
+func (t *RepairText) pushPath(segment pathSegment) {
+	t.pointerDepth = min(t.pointerDepth, len(t.path))
+	t.path = append(t.path, segment)
+}
+
+func (t *RepairText) popPath() {
+	t.path = t.path[:len(t.path)-1]
+}
+
+// pointer returns the JSON Pointer of the value being parsed. Only the
+// segments which have not been built before are built.
+func (t *RepairText) pointer() string {
+	depth := len(t.path)
+	if t.pointerDepth < depth {
+		var sb strings.Builder
+		if t.pointerDepth > 0 {
+			sb.WriteString(t.pointers[:t.pointerEnds[t.pointerDepth-1]])
+		}
+		t.pointerEnds = t.pointerEnds[:t.pointerDepth]
+		for _, segment := range t.path[t.pointerDepth:] {
+			sb.WriteByte('/')
+			if segment.isKey {
+				key := t.objectKey(segment.keyStart, segment.keyEnd)
+				key = strings.ReplaceAll(key, "~", "~0")
+				sb.WriteString(strings.ReplaceAll(key, "/", "~1"))
+			} else {
+				sb.WriteString(strconv.Itoa(segment.index))
+			}
+			t.pointerEnds = append(t.pointerEnds, sb.Len())
+		}
+		t.pointers = sb.String()
+		t.pointerDepth = depth
+	}
+	if depth == 0 {
+		return ""
+	}
+	return t.pointers[:t.pointerEnds[depth-1]]
+}
+
+// objectKey returns the key which has been written to the output between
+// the given output positions.
+func (t *RepairText) objectKey(start, end int) string {
+	raw := t.outputRange(start, end)
+	var key string
+	if err := json.Unmarshal([]byte(raw), &key); err != nil {
+		return raw
+	}
+	return key
+}
+
+// outputValue returns the value which has been written to the output
+// starting at the given output position, without whitespace and comments.
+func (t *RepairText) outputValue(start int) string {
+	return t.outputRange(start, t.output.Len())
+}
+
+func (t *RepairText) outputRange(start, end int) string {
+	var runes []rune
+	for i := start; i < end; i++ {
+		if !t.output.isComment(i) {
+			runes = append(runes, t.output.runes[i])
+		}
 	}
-	return "", UnexpectedCharacterError.MessageAppend(fmt.Sprintf(`"%s"`, string(t.text[t.i]))).At(t.i)
+	return strings.TrimSpace(string(runes))
 }
 
 func (t *RepairText) parseValue() (bool, error) {
 	var processed bool
 	var err error
 	t.parseWhitespaceAndSkipComments()
+	t.valueStart = t.i
 	defer func() {
 		if err == nil {
+			if len(t.path) == 0 {
+				t.valueEndSeen = t.endSeen
+			}
 			t.parseWhitespaceAndSkipComments()
 		}
 	}()
@@ -111,22 +448,35 @@ func (t *RepairText) parseValue() (bool, error) {
 	} else if processed {
 		return true, nil
 	}
+	start, handled := t.output.Len(), t.handled
 	if processed, err = t.parseString(false); err != nil {
 		return false, err
 	} else if processed {
+		t.emitValue(start)
 		return true, nil
 	}
+	numberStart := t.i
 	if processed, err = t.parseNumber(); err != nil {
-		return false, err
+		if !t.opts.bestEffort {
+			return false, err
+		}
+		// the number is repaired as unquoted string below
+		t.i, err = numberStart, nil
 	} else if processed {
+		t.emitValue(start)
 		return true, nil
 	}
 	if processed = t.parseKeywords(); processed {
+		t.emitValue(start)
 		return true, nil
 	}
-	if processed, err = t.parseUnquotedString(); err != nil {
+	if processed, err = t.parseUnquotedString(false); err != nil {
 		return false, err
 	} else if processed {
+		// the argument of a function call has been emitted already
+		if t.handled == handled {
+			t.emitValue(start)
+		}
 		return true, nil
 	}
 	//t.parseWhitespaceAndSkipComments()
@@ -150,39 +500,84 @@ func (t *RepairText) parseWhitespaceAndSkipComments() bool {
 }
 
 func (t *RepairText) parseComment() bool {
+	start := t.i
 
 	if t.CharCode(t.i) == codeSlash && t.CharCode(t.i+1) == codeAsterisk {
-		for t.i < len(t.text) && !t.atEndOfBlockComment() {
+		for !t.atEnd() && !t.atEndOfBlockComment() {
 			t.i++
 		}
 		t.i += 2
+		t.skipComment(start, "*/")
 		return true
 	}
 
 	if t.CharCode(t.i) == codeSlash && t.CharCode(t.i+1) == codeSlash {
-		for t.i < len(t.text) && t.CharCode(t.i) != codeNewline {
+		for !t.atEnd() && t.CharCode(t.i) != codeNewline {
 			t.i++
 		}
+		t.skipComment(start, "\n")
 		return true
 	}
 	return false
 }
 
+// skipComment removes the comment between start and the current position,
+// or keeps it when WithComments is used. A kept comment which is cut off by
+// the end of the text is ended with end.
+func (t *RepairText) skipComment(start int, end string) {
+	unterminated := t.i > len(t.text)
+	t.i = min(t.i, len(t.text))
+	if !t.opts.comments {
+		t.report(RepairComment, start, t.i)
+		t.token(TokenComment, start, t.i, true)
+		return
+	}
+	t.comments = append(t.comments, [2]int{start, t.i})
+	t.output.append(start, t.Slice(start, t.i)...)
+	if unterminated {
+		t.report(RepairComment, len(t.text), len(t.text))
+		t.output.appendString(-1, end)
+	} else if t.i == len(t.text) && end == "\n" {
+		// text after a line comment must go on the next line
+		t.output.appendString(-1, end)
+	}
+	t.token(TokenComment, start, t.i, unterminated)
+}
+
+// inComment returns whether the given position in the text is part of a
+// kept comment.
+func (t *RepairText) inComment(pos int) bool {
+	for i := len(t.comments) - 1; i >= 0; i-- {
+		if pos >= t.comments[i][1] {
+			return false
+		}
+		if pos >= t.comments[i][0] {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *RepairText) parseObject() (bool, error) {
 	var err error
 	if t.CharCode(t.i) == codeOpeningBrace {
-		t.output = append(t.output, '{')
+		t.token(TokenPunctuation, t.i, t.i+1, false)
+		t.emit(Handler.OnObjectStart)
+		t.output.append(t.i, '{')
 		t.i++
 		t.parseWhitespaceAndSkipComments()
 
 		var initial = true
-		for t.i < len(t.text) && t.CharCode(t.i) != codeClosingBrace {
+		for !t.atEnd() && t.CharCode(t.i) != codeClosingBrace {
 			var processedComma bool
+			first := initial
 
 			if !initial {
 				processedComma = t.parseCharacter(codeComma)
 				if !processedComma {
-					t.output = InsertBeforeLastWhitespace(t.output, ",")
+					at := t.output.insertBeforeLastWhitespace(",")
+					t.reportInsert(RepairMissingComma, &t.output, at)
+					t.insertToken(TokenPunctuation, ",", &t.output, at)
 				}
 				t.parseWhitespaceAndSkipComments()
 			} else {
@@ -190,13 +585,23 @@ func (t *RepairText) parseObject() (bool, error) {
 				initial = false
 			}
 
+			if t.opts.bestEffort {
+				t.skipUnexpected(func(r rune) bool {
+					return cannotStartValue(r) && r != codeClosingBrace && r != codeClosingBracket
+				})
+			}
+			start := t.i
+			t.valueStart = start
+			wasTruncated := t.truncated
+			keyStart := t.output.Len()
+			partialKeys := len(t.partial)
 			var processedKey bool
 			processedKey, err = t.parseString(false)
 			if err != nil {
 				return false, err
 			}
 			if !processedKey {
-				processedKey, err = t.parseUnquotedString()
+				processedKey, err = t.parseUnquotedString(true)
 				if err != nil {
 					return false, err
 				}
@@ -205,19 +610,40 @@ func (t *RepairText) parseObject() (bool, error) {
 				chcode := t.CharCode(t.i)
 				if chcode == codeClosingBrace || chcode == codeOpeningBrace ||
 					chcode == codeClosingBracket || chcode == codeOpeningBracket ||
-					t.i >= len(t.text) || t.i < 0 {
-					t.output = []rune(stripLastOccurrence(string(t.output), ",", false))
+					t.atEnd() || t.i < 0 {
+					// the first key is not preceded by a comma
+					if !first {
+						t.reportTrailingComma(t.output.stripLastOccurrence(codeComma, false))
+					}
 				} else {
 					return false, ObjectKeyExpectedError.At(t.i)
 				}
 				break
 			}
+			keyEnd := t.output.Len()
+			t.pushPath(pathSegment{keyStart: keyStart, keyEnd: keyEnd, isKey: true})
+			t.emit(func(h Handler) { h.OnKey(t.objectKey(keyStart, keyEnd)) })
+			// an unterminated key has been recorded as a string of the object
+			for i := partialKeys; i < len(t.partial); i++ {
+				t.partial[i] = Partial{Path: t.pointer(), Kind: PartialKey}
+			}
 			t.parseWhitespaceAndSkipComments()
 			processedColon := t.parseCharacter(codeColon)
-			truncatedtext := t.i >= len(t.text)
+			truncatedtext := t.atEnd()
+			if !processedColon && !truncatedtext && t.opts.bestEffort && !IsStartOfValue(t.text[t.i]) {
+				t.skipUnexpected(func(r rune) bool {
+					return cannotStartValue(r) && r != codeColon && r != codeComma &&
+						r != codeClosingBrace && r != codeClosingBracket
+				})
+				processedColon = t.parseCharacter(codeColon)
+				truncatedtext = t.atEnd()
+			}
 			if !processedColon {
-				if truncatedtext || IsStartOfValue(t.text[t.i]) {
-					t.output = InsertBeforeLastWhitespace(t.output, ":")
+				if truncatedtext || t.opts.bestEffort || IsStartOfValue(t.text[t.i]) {
+					t.markTruncated()
+					at := t.output.insertBeforeLastWhitespace(":")
+					t.reportInsert(RepairMissingColon, &t.output, at)
+					t.insertToken(TokenPunctuation, ":", &t.output, at)
 				} else {
 					return false, ColonExpectedError.At(t.i)
 				}
@@ -227,19 +653,27 @@ func (t *RepairText) parseObject() (bool, error) {
 				return false, err
 			}
 			if !processedValue {
-				if truncatedtext || processedColon {
-					t.output = append(t.output, []rune("null")...)
+				if truncatedtext || processedColon || t.opts.bestEffort {
+					t.markPartial(PartialNull)
+					t.insertNull()
 				} else {
 					return false, ColonExpectedError.At(t.i)
 				}
 			}
+			t.trackElement(start, wasTruncated, false)
+			t.popPath()
 		}
 		if t.CharCode(t.i) == codeClosingBrace {
-			t.output = append(t.output, '}')
+			t.token(TokenPunctuation, t.i, t.i+1, false)
+			t.output.append(t.i, '}')
 			t.i++
 		} else {
-			t.output = InsertBeforeLastWhitespace(t.output, "}")
+			t.markPartial(PartialObject)
+			at := t.output.insertBeforeLastWhitespace("}")
+			t.reportInsert(RepairMissingClosingBrace, &t.output, at)
+			t.insertToken(TokenPunctuation, "}", &t.output, at)
 		}
+		t.emit(Handler.OnObjectEnd)
 		return true, nil
 	}
 	return false, nil
@@ -247,58 +681,92 @@ func (t *RepairText) parseObject() (bool, error) {
 
 func (t *RepairText) parseArray() (bool, error) {
 	if t.CharCode(t.i) == codeOpeningBracket {
-		t.output = append(t.output, '[')
+		t.token(TokenPunctuation, t.i, t.i+1, false)
+		t.emit(Handler.OnArrayStart)
+		t.output.append(t.i, '[')
 		t.i++
 		t.parseWhitespaceAndSkipComments()
 
 		initial := true
-		for t.i < len(t.text) && t.CharCode(t.i) != codeClosingBracket {
+		for index := 0; !t.atEnd() && t.CharCode(t.i) != codeClosingBracket; index++ {
 			if !initial {
 				processedComma := t.parseCharacter(codeComma)
 				if !processedComma {
-					t.output = InsertBeforeLastWhitespace(t.output, ",")
+					at := t.output.insertBeforeLastWhitespace(",")
+					t.reportInsert(RepairMissingComma, &t.output, at)
+					t.insertToken(TokenPunctuation, ",", &t.output, at)
 				}
 			} else {
 				initial = false
 			}
+			if t.opts.bestEffort {
+				t.skipUnexpected(func(r rune) bool {
+					return cannotStartValue(r) && r != codeClosingBracket && r != codeClosingBrace
+				})
+			}
+			start := t.i
+			wasTruncated := t.truncated
+			t.pushPath(pathSegment{index: index})
 			processedValue, err := t.parseValue()
 			if err != nil {
 				return false, err
 			}
+			t.trackElement(start, wasTruncated, true)
+			t.popPath()
 			if !processedValue {
-				t.output = []rune(stripLastOccurrence(string(t.output), ",", false))
+				// the first element is not preceded by a comma
+				if index > 0 {
+					t.reportTrailingComma(t.output.stripLastOccurrence(codeComma, false))
+				}
 				break
 			}
 		}
 		if t.CharCode(t.i) == codeClosingBracket {
-			t.output = append(t.output, ']')
+			t.token(TokenPunctuation, t.i, t.i+1, false)
+			t.output.append(t.i, ']')
 			t.i++
 		} else {
-			t.output = InsertBeforeLastWhitespace(t.output, "]")
+			t.markPartial(PartialArray)
+			at := t.output.insertBeforeLastWhitespace("]")
+			t.reportInsert(RepairMissingClosingBracket, &t.output, at)
+			t.insertToken(TokenPunctuation, "]", &t.output, at)
 		}
+		t.emit(Handler.OnArrayEnd)
 		return true, nil
 	}
 	return false, nil
 }
 
-func (t *RepairText) parseUnquotedString() (bool, error) {
+// parseUnquotedString parses an unquoted string, or a function call or
+// undefined. In best effort mode, an object key is always a string.
+func (t *RepairText) parseUnquotedString(isKey bool) (bool, error) {
+	symbolOnly := isKey && t.opts.bestEffort
 	start := t.i
-	for t.i < len(t.text) && !IsDelimiter(t.text[t.i]) {
+	for !t.atEnd() && !IsDelimiter(t.text[t.i]) {
 		t.i++
 	}
 	if t.i > start {
-		if t.CharCode(t.i) == codeOpenParenthesis {
+		t.markPartial(PartialString)
+		if t.CharCode(t.i) == codeOpenParenthesis && !symbolOnly {
+			t.token(TokenSymbol, start, t.i, true)
+			t.token(TokenPunctuation, t.i, t.i+1, true)
 			t.i++
-			_, err := t.parseValue()
+			processedValue, err := t.parseValue()
 			if err != nil {
 				return false, err
 			}
+			if !processedValue && t.opts.bestEffort {
+				t.insertNull()
+			}
 			if t.CharCode(t.i) == codeCloseParenthesis {
+				t.token(TokenPunctuation, t.i, t.i+1, true)
 				t.i++
 				if t.CharCode(t.i) == codeSemicolon {
+					t.token(TokenPunctuation, t.i, t.i+1, true)
 					t.i++
 				}
 			}
+			t.report(RepairFunctionCall, start, t.i)
 			return true, nil
 		} else {
 			// repair unquoted string
@@ -307,16 +775,40 @@ func (t *RepairText) parseUnquotedString() (bool, error) {
 				t.i--
 			}
 			symbol := string(t.Slice(start, t.i))
-			if symbol == "undefined" {
-				t.output = append(t.output, []rune("null")...)
+			if symbol == "undefined" && !symbolOnly {
+				t.report(RepairUndefined, start, t.i)
+				t.output.appendString(start, "null")
 			} else {
 				ss, _ := json.Marshal(symbol) // TODO
-				t.output = append(t.output, []rune(string(ss))...)
+				if string(ss) == `"`+symbol+`"` {
+					t.output.append(-1, '"')
+					t.output.appendString(start, symbol)
+					t.output.append(-1, '"')
+				} else {
+					// the runes of an escape sequence originate from the
+					// escaped rune
+					t.output.append(-1, '"')
+					for k, r := range []rune(symbol) {
+						escaped, _ := json.Marshal(string(r))
+						for _, e := range []rune(string(escaped[1 : len(escaped)-1])) {
+							t.output.append(start+k, e)
+						}
+					}
+					t.output.append(-1, '"')
+				}
 			}
 			if t.CharCode(t.i) == codeDoubleQuote {
 				// we had a missing start quote, but now we encountered the end quote, so we can skip that one
 				t.i++
+				t.report(RepairMissingStartQuote, start, t.i)
+			} else if symbol != "undefined" || symbolOnly {
+				kind := RepairUnquotedString
+				if isKey {
+					kind = RepairUnquotedKey
+				}
+				t.report(kind, start, t.i)
 			}
+			t.token(TokenSymbol, start, t.i, true)
 
 			return true, nil
 		}
@@ -326,7 +818,8 @@ func (t *RepairText) parseUnquotedString() (bool, error) {
 
 func (t *RepairText) parseCharacter(code rune) bool {
 	if t.CharCode(t.i) == code && t.i < len(t.text) {
-		t.output = append(t.output, t.text[t.i])
+		t.token(TokenPunctuation, t.i, t.i+1, false)
+		t.output.append(t.i, t.text[t.i])
 		t.i++
 		return true
 	}
@@ -342,9 +835,11 @@ func (t *RepairText) skipCharacter(code rune) bool {
 }
 
 func (t *RepairText) parseWhitespace() bool {
+	start := t.i
+	repairsBefore := len(t.repairs)
 	var whitespace string
 	var normal bool
-	for t.i < len(t.text) {
+	for !t.atEnd() {
 		charCode := t.CharCode(t.i)
 		normal = IsWhitespace(charCode)
 		if normal || IsSpecialWhitespace(charCode) {
@@ -352,6 +847,7 @@ func (t *RepairText) parseWhitespace() bool {
 				whitespace += string(t.text[t.i])
 			} else {
 				// repair special whitespace
+				t.report(RepairSpecialWhitespace, t.i, t.i+1)
 				whitespace += " "
 			}
 			t.i++
@@ -360,7 +856,8 @@ func (t *RepairText) parseWhitespace() bool {
 		}
 	}
 	if len(whitespace) > 0 {
-		t.output = append(t.output, []rune(whitespace)...)
+		t.token(TokenWhitespace, start, t.i, len(t.repairs) > repairsBefore)
+		t.output.appendString(start, whitespace)
 		return true
 	}
 	return false
@@ -371,6 +868,13 @@ func (t *RepairText) atEndOfBlockComment() bool {
 }
 
 func (t *RepairText) parseString(stopAtDelimiter bool) (bool, error) {
+	return t.parseStringEndingAt(stopAtDelimiter, -1)
+}
+
+// parseStringEndingAt parses a string like parseString. When end is not
+// negative, the string ends at the quote at position end, and all quotes
+// before it are part of the string.
+func (t *RepairText) parseStringEndingAt(stopAtDelimiter bool, end int) (bool, error) {
 	var skipEscapeChars = t.CharCode(t.i) == codeBackslash
 	if skipEscapeChars {
 		t.i++
@@ -394,21 +898,27 @@ func (t *RepairText) parseString(stopAtDelimiter bool) (bool, error) {
 
 		//t.output = append(t.output, '"')
 		iBefore := t.i
+		stableBefore := t.stable
+		repairsBefore := len(t.repairs)
 
-		tmpOutput := []rune(`"`)
+		tmpOutput := newOutputBuffer()
+		tmpOutput.append(t.i, '"')
 		t.i++
 		var isEndofString func(rune) bool
-		if stopAtDelimiter {
+		switch {
+		case end >= 0:
+			isEndofString = func(rune) bool { return t.i >= end }
+		case stopAtDelimiter:
 			isEndofString = IsDelimiter
-		} else {
+		default:
 			isEndofString = isEndQuote
 		}
 
-		for t.i < len(t.text) && !isEndofString(t.CharCode(t.i)) {
+		for !t.atEnd() && !isEndofString(t.CharCode(t.i)) {
 			if t.CharCode(t.i) == codeBackslash {
 				char := t.Char(t.i + 1)
 				if _, found := escapeCharacters[char]; found {
-					tmpOutput = append(tmpOutput, t.Slice(t.i, t.i+2)...)
+					tmpOutput.append(t.i, t.Slice(t.i, t.i+2)...)
 					t.i += 2
 				} else if char == "u" {
 					var j = 2
@@ -416,31 +926,49 @@ func (t *RepairText) parseString(stopAtDelimiter bool) (bool, error) {
 						j++
 					}
 					if j == 6 {
-						tmpOutput = append(tmpOutput, t.Slice(t.i, t.i+6)...)
+						tmpOutput.append(t.i, t.Slice(t.i, t.i+6)...)
 						t.i += 6
 					} else if (t.i + j) >= len(t.text) {
 						t.i = len(t.text)
+					} else if t.opts.bestEffort {
+						t.report(RepairInvalidEscape, t.i, t.i+2)
+						tmpOutput.appendString(t.i+1, "u")
+						t.i += 2
 					} else {
 						return false, InvalidUnicodeCharacter(string(t.Slice(t.i, t.i+6))).At(t.i)
 					}
+				} else if code := t.CharCode(t.i + 1); t.opts.bestEffort && code >= 0 && !IsValidStringCharacter(code) {
+					// the escaped character itself needs a repair
+					t.report(RepairInvalidEscape, t.i, t.i+1)
+					t.i++
 				} else {
-					tmpOutput = append(tmpOutput, []rune(char)...)
-					t.i += 2
+					t.report(RepairInvalidEscape, t.i, t.i+2)
+					tmpOutput.appendString(t.i+1, char)
+					// a backslash may end the text
+					t.i = min(t.i+2, len(t.text))
 				}
 			} else {
 				char := t.Char(t.i)
 				code := t.CharCode(t.i)
 				if code == codeDoubleQuote && t.CharCode(t.i-1) != codeBackslash {
-					tmpOutput = append(tmpOutput, []rune("\\"+char)...)
+					t.report(RepairUnescapedQuote, t.i, t.i+1)
+					tmpOutput.append(-1, codeBackslash)
+					tmpOutput.appendString(t.i, char)
 					t.i++
 				} else if IsControlCharacter(code) {
-					tmpOutput = append(tmpOutput, []rune(controlCharacters[char])...)
+					t.report(RepairControlCharacter, t.i, t.i+1)
+					tmpOutput.append(-1, codeBackslash)
+					tmpOutput.appendString(t.i, controlCharacters[char][1:])
 					t.i++
 				} else {
-					if !IsValidStringCharacter(code) {
+					if IsValidStringCharacter(code) {
+						tmpOutput.appendString(t.i, char)
+					} else if t.opts.bestEffort {
+						t.report(RepairControlCharacter, t.i, t.i+1)
+						tmpOutput.appendString(-1, fmt.Sprintf(`\u%04x`, code))
+					} else {
 						return false, InvalidUnicodeCharacter(char).At(t.i)
 					}
-					tmpOutput = append(tmpOutput, []rune(char)...)
 					t.i++
 				}
 			}
@@ -453,25 +981,69 @@ func (t *RepairText) parseString(stopAtDelimiter bool) (bool, error) {
 		}
 
 		var hasEndQuote = IsQuote(t.CharCode(t.i))
-		var valid = hasEndQuote && ((t.i+1) >= len(t.text) || IsDelimiter(nextNonWhiteSpaceCharacter(t.text, t.i+1)))
-		if !valid && !stopAtDelimiter {
-			t.i = iBefore
-			return t.parseString(true)
+		var next = nextNonWhiteSpaceCharacter(t.text, t.i+1)
+		if hasEndQuote && next == -1 {
+			// text appended after the end quote may invalidate it
+			t.endSeen = true
+		}
+		var valid = hasEndQuote && ((t.i+1) >= len(t.text) || IsDelimiter(next))
+		// a retry would change a string which has been returned before
+		if !valid && !stopAtDelimiter && end < 0 && !t.opts.prefixStable {
+			// the string may end at the first delimiter, at this quote, or
+			// at a later quote followed by a delimiter
+			ends := t.laterStringEnds(isEndQuote)
+			switch choice := t.choose(2 + len(ends)); {
+			case choice == 0:
+				t.i = iBefore
+				t.repairs = t.repairs[:repairsBefore]
+				return t.parseString(true)
+			case choice >= 2:
+				t.i = iBefore
+				t.repairs = t.repairs[:repairsBefore]
+				return t.parseStringEndingAt(false, ends[choice-2])
+			}
 		}
 		if hasEndQuote {
-			tmpOutput = append(tmpOutput, []rune(`"`)...)
+			if !IsDoubleQuote(t.text[iBefore]) || !IsDoubleQuote(t.text[t.i]) {
+				t.report(RepairQuotes, iBefore, t.i+1)
+			}
+			tmpOutput.append(t.i, '"')
 			t.i++
 		} else {
-			tmpOutput = InsertBeforeLastWhitespace(tmpOutput, `"`)
+			if !IsDoubleQuote(t.text[iBefore]) {
+				t.report(RepairQuotes, iBefore, t.i)
+			}
+			if stableBefore < 0 && t.stable >= 0 {
+				// the string content read so far is final, only the end
+				// quote is not
+				t.stable = t.output.Len() + len(trimTrailingWhitespace(tmpOutput.runes))
+			}
+			t.markPartial(PartialString)
+			t.reportInsert(RepairMissingEndQuote, &tmpOutput, tmpOutput.insertBeforeLastWhitespace(`"`))
+		}
+		if skipEscapeChars {
+			t.report(RepairEscapedString, iBefore-1, t.i)
+			t.token(TokenString, iBefore-1, t.i, true)
+		} else {
+			t.token(TokenString, iBefore, t.i, len(t.repairs) > repairsBefore)
 		}
 
-		t.output = append(t.output, tmpOutput...)
+		t.output.appendBuffer(&tmpOutput)
 		_, err := t.parseConcatenatedString()
 		if err != nil {
-			return false, fmt.Errorf("failed to parseConcatenatedString: %v", err)
+			return false, fmt.Errorf("failed to parseConcatenatedString: %w", err)
 		}
 		return true, nil
 	}
+	if skipEscapeChars {
+		// a backslash which does not escape a quote is skipped, in best
+		// effort mode together with the text after it
+		if t.opts.bestEffort {
+			t.i--
+		} else {
+			t.skipText(t.i-1, t.i)
+		}
+	}
 	return false, nil
 }
 
@@ -480,19 +1052,26 @@ func (t *RepairText) parseConcatenatedString() (bool, error) {
 	t.parseWhitespaceAndSkipComments()
 	for t.CharCode(t.i) == codePlus {
 		processed = true
+		start := t.i
+		t.token(TokenPunctuation, t.i, t.i+1, true)
 		t.i++
 		t.parseWhitespaceAndSkipComments()
-		t.output = []rune(stripLastOccurrence(string(t.output), `"`, true))
-		start := len(t.output)
+		t.output.stripLastOccurrence(codeDoubleQuote, true)
+		quote := t.output.Len()
 		parsedStr, err := t.parseString(false)
 		if err != nil {
 			return false, err
 		}
 		if parsedStr {
-			t.output = RemoveAtIndex(t.output, start, 1)
+			t.output.removeAt(quote, 1)
+			if t.stable > quote {
+				// the start quote of the concatenated string is removed
+				t.stable--
+			}
 		} else {
-			t.output = InsertBeforeLastWhitespace(t.output, `"`)
+			t.output.insertBeforeLastWhitespace(`"`)
 		}
+		t.report(RepairConcatenatedString, start, t.i)
 	}
 	return processed, nil
 }
@@ -503,6 +1082,11 @@ func (t *RepairText) skipEscapeCharacter() bool {
 
 func (t *RepairText) parseNumber() (bool, error) {
 	start := t.i
+	// in best effort mode, a dot or exponent without digits before it is not
+	// a number
+	if t.opts.bestEffort && t.CharCode(t.i) != codeMinus && !IsDigit(t.CharCode(t.i)) {
+		return false, nil
+	}
 	if t.CharCode(t.i) == codeMinus {
 		t.i++
 		if ok, err := t.expectDigitOrRepair(start); err != nil {
@@ -542,11 +1126,17 @@ func (t *RepairText) parseNumber() (bool, error) {
 		}
 	}
 	if t.i > start {
+		t.markPartial(PartialNumber)
 		numStr := string(t.Slice(start, t.i))
-		if regexNumberWithLeadingZero.MatchString(numStr) {
-			t.output = append(t.output, []rune(`"`+numStr+`"`)...)
+		leadingZero := regexNumberWithLeadingZero.MatchString(numStr)
+		t.token(TokenNumber, start, t.i, leadingZero)
+		if leadingZero {
+			t.report(RepairLeadingZero, start, t.i)
+			t.output.append(-1, '"')
+			t.output.appendString(start, numStr)
+			t.output.append(-1, '"')
 		} else {
-			t.output = append(t.output, []rune(numStr)...)
+			t.output.appendString(start, numStr)
 		}
 		return true, nil
 	}
@@ -554,8 +1144,12 @@ func (t *RepairText) parseNumber() (bool, error) {
 }
 
 func (t *RepairText) expectDigitOrRepair(start int) (bool, error) {
-	if t.i >= len(t.text) {
-		t.output = append(t.output, append(t.Slice(start, t.i), '0')...)
+	if t.atEnd() {
+		t.markPartial(PartialNumber)
+		t.report(RepairTruncatedNumber, t.i, t.i)
+		t.token(TokenNumber, start, t.i, true)
+		t.output.append(start, t.Slice(start, t.i)...)
+		t.output.append(-1, '0')
 		return true, nil
 	} else {
 		err := t.expectDigit(start)
@@ -586,7 +1180,11 @@ func (t *RepairText) parseKeywords() bool {
 
 func (t *RepairText) parseKeyword(name, value string) bool {
 	if string(t.Slice(t.i, t.i+len(name))) == name {
-		t.output = append(t.output, []rune(value)...)
+		if name != value {
+			t.report(RepairPythonKeyword, t.i, t.i+len(name))
+		}
+		t.token(TokenKeyword, t.i, t.i+len(name), name != value)
+		t.output.appendString(t.i, value)
 		t.i += len(name)
 		return true
 	}
@@ -604,23 +1202,31 @@ func (t *RepairText) parseNewlineDelimitedJSON() error {
 	initial := true
 	processedValue := true
 	var err error
-	for processedValue {
+	for index := 1; processedValue; index++ {
 		if !initial {
 			processedComma := t.parseCharacter(codeComma)
 			if !processedComma {
-				t.output = InsertBeforeLastWhitespace(t.output, ",")
+				at := t.output.insertBeforeLastWhitespace(",")
+				t.reportInsert(RepairMissingComma, &t.output, at)
+				t.insertToken(TokenPunctuation, ",", &t.output, at)
 			}
 		} else {
 			initial = false
 		}
+		t.pushPath(pathSegment{index: index})
 		processedValue, err = t.parseValue()
+		t.popPath()
 		if err != nil {
 			return err
 		}
 	}
 	if !processedValue {
-		t.output = []rune(stripLastOccurrence(string(t.output), ",", false))
+		t.reportTrailingComma(t.output.stripLastOccurrence(codeComma, false))
 	}
-	t.output = append(append([]rune("[\n"), t.output...), []rune("\n]")...)
+	t.report(RepairNewlineDelimited, 0, len(t.text))
+	t.output.insert(0, "[\n")
+	t.insertToken(TokenPunctuation, "[", &t.output, 0)
+	t.output.appendString(-1, "\n]")
+	t.insertToken(TokenPunctuation, "]", &t.output, t.output.Len())
 	return nil
 }
@@ -1,6 +1,8 @@
 package jsonrepair
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -155,6 +157,13 @@ func TestRepairValidJSON(t *testing.T) {
 				{"[\na,\nb\n]", "[\n\"a\",\n\"b\"\n]"},
 			},
 		},
+		{
+			name: "should repair invalid escapes",
+			cases: []Case{
+				{`"a\x"`, `"ax"`},
+				{`"a\`, `"a"`},
+			},
+		},
 		{
 			name: "should add missing end quote",
 			cases: []Case{
@@ -430,6 +439,8 @@ func TestRepairValidJSON(t *testing.T) {
 				{`[[1,2,3,`, `[[1,2,3]]`},
 				{"{\n\"values\":[1,2,3\n}", "{\n\"values\":[1,2,3]\n}"},
 				{"{\n\"values\":[1,2,3\n", "{\n\"values\":[1,2,3]}\n"},
+				{`[1,[}]`, `[1,[]]`},
+				{`[1,{]`, `[1,{}]`},
 			},
 		},
 		{
@@ -670,6 +681,10 @@ func TestNonRepairable(t *testing.T) {
 			Input:  `"\u26"`,
 			ErrStr: `Invalid unicode character "\u26"" at position 1`, // TODO "\u26" instead of "\u26""
 		},
+		{
+			Input:  `/* a`,
+			ErrStr: `Unexpected end of json string at position 4`,
+		},
 		{
 			Input:  `"\uZ000"`,
 			ErrStr: `Invalid unicode character "\uZ000" at position 1`,
@@ -691,3 +706,176 @@ func TestNonRepairable(t *testing.T) {
 		t.Log("cases passed for group: should throw an exception in case of non-repairable issues")
 	}
 }
+
+func TestTruncationPolicy(t *testing.T) {
+	ts := []struct {
+		Input     string
+		Policy    TruncationPolicy
+		Want      string
+		Truncated string
+	}{
+		{`[{"id":1,"name":"a"},{"id":2,"na`, TruncationComplete, `[{"id":1,"name":"a"},{"id":2,"na":null}]`, ``},
+		{`[{"id":1,"name":"a"},{"id":2,"na`, TruncationDrop, `[{"id":1,"name":"a"}]`, `/1`},
+		{`[{"id":1,"name":"a"},{"id":2,"na`, TruncationMark, `[{"id":1,"name":"a"},{"id":2,"na":null}]`, `/1`},
+		{`{"items":[{"id":1},{"id":2,"tags":["x","y`, TruncationDrop, `{"items":[{"id":1}]}`, `/items/1`},
+		{`{"a":1,"b":{"c":"d`, TruncationDrop, `{"a":1,"b":{}}`, `/b/c`},
+		{`{"a":1,"b":"c`, TruncationDrop, `{"a":1}`, `/b`},
+		{`{"a":1,"b`, TruncationDrop, `{"a":1}`, `/b`},
+		{`[1,2,3`, TruncationDrop, `[1,2]`, `/2`},
+		{`[1,2,3,`, TruncationDrop, `[1,2,3]`, ``},
+		{`[{"a":1}`, TruncationDrop, `[{"a":1}]`, ``},
+		{`[{"a/b":{"c~":[1`, TruncationMark, `[{"a/b":{"c~":[1]}}]`, `/0`},
+		{`"abc`, TruncationDrop, `"abc"`, ``},
+		{`{"a":1}`, TruncationDrop, `{"a":1}`, ``},
+	}
+
+	for _, tt := range ts {
+		res, err := Repair(tt.Input, WithTruncationPolicy(tt.Policy))
+		if err != nil {
+			t.Errorf("case: %s, policy: %d, err: %v", tt.Input, tt.Policy, err)
+			continue
+		}
+		if res.Output != tt.Want || res.Truncated != tt.Truncated {
+			t.Errorf("case: %s, policy: %d, got: %s (%s), expect: %s (%s)", tt.Input, tt.Policy, res.Output, res.Truncated, tt.Want, tt.Truncated)
+		}
+	}
+}
+
+func TestPartial(t *testing.T) {
+	ts := []struct {
+		Input   string
+		Want    string
+		Partial []Partial
+	}{
+		{`{"a":1}`, `{"a":1}`, nil},
+		{`{"a":"hel`, `{"a":"hel"}`, []Partial{{"/a", PartialString}, {"", PartialObject}}},
+		{`{"a":`, `{"a":null}`, []Partial{{"/a", PartialNull}, {"", PartialObject}}},
+		{`{"ke`, `{"ke":null}`, []Partial{{"/ke", PartialKey}, {"/ke", PartialNull}, {"", PartialObject}}},
+		{`[1,[2.`, `[1,[2.0]]`, []Partial{{"/1/0", PartialNumber}, {"/1", PartialArray}, {"", PartialArray}}},
+		{`[1,{"b":tru`, `[1,{"b":"tru"}]`, []Partial{{"/1/b", PartialString}, {"/1", PartialObject}, {"", PartialArray}}},
+		{`[1,2,`, `[1,2]`, []Partial{{"", PartialArray}}},
+		{`[{"a/b":1},{"c":[`, `[{"a/b":1},{"c":[]}]`, []Partial{{"/1/c", PartialArray}, {"/1", PartialObject}, {"", PartialArray}}},
+	}
+
+	for _, tt := range ts {
+		res, err := Repair(tt.Input)
+		if err != nil {
+			t.Errorf("case: %s, err: %v", tt.Input, err)
+			continue
+		}
+		if res.Output != tt.Want || fmt.Sprint(res.Partial) != fmt.Sprint(tt.Partial) {
+			t.Errorf("case: %s, got: %s %v, expect: %s %v", tt.Input, res.Output, res.Partial, tt.Want, tt.Partial)
+		}
+	}
+}
+
+func TestPartialDeep(t *testing.T) {
+	// the pointers of the enclosing values are not built again
+	depth := 20000
+	res, err := Repair(strings.Repeat(`{"a":`, depth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Partial) != depth+1 || res.Partial[0].Path != strings.Repeat("/a", depth) || res.Partial[depth].Path != "" {
+		t.Errorf("unexpected partial values %d", len(res.Partial))
+	}
+}
+
+func TestPrefixStable(t *testing.T) {
+	docs := []string{
+		`{"id": 1, "name": "foo, bar", "tags": ["a", "b"], "score": -12.5e3, "ok": true, "none": null}`,
+		`[{"a": "x y"}, {"b": [1, 2, [3]]}, "", 0, 100]`,
+		"{a: 'single', b: \"quote \\\"inner\\\" \\u2605\", /* comment */ c: [1 2 3], // line\n d: None}",
+		`{"text": "hello" + " world", "n": 007, "u": undefined}`,
+		`{"a": "foo "bar" baz", "b": 2}`,
+		`[1, 2, 3, ]`,
+		"{\"a\":\u00a0\u201cfoo\u201d, \"b\": [true,false ,null], \"c\": {\"d\": \"\\\\\"}}",
+		"callback({\"a\": \"line\nbreak\"});",
+		"{\"a\" \"b\"\n\"c\": 1 \"d\": [ 1e-5, 0.5 ]}",
+	}
+
+	for _, doc := range docs {
+		runes := []rune(doc)
+		var stable []string
+		var outputs []string
+		for i := 0; i <= len(runes); i++ {
+			res, err := Repair(string(runes[:i]), WithPrefixStable())
+			if err != nil {
+				stable = append(stable, "")
+				outputs = append(outputs, "")
+				continue
+			}
+			stable = append(stable, res.StableOutput())
+			outputs = append(outputs, res.Output)
+		}
+		for i := range stable {
+			for j := i + 1; j < len(outputs); j++ {
+				if outputs[j] != "" && !strings.HasPrefix(outputs[j], stable[i]) {
+					t.Errorf("doc: %s, stable output %q of prefix %d is no prefix of output %q of prefix %d", doc, stable[i], i, outputs[j], j)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	ts := []struct {
+		Input  string
+		Expect string
+	}{
+		{"{\n  // c\n  a: 1, // x, y\n  b: [1 2,], /* z */\n}", "{\n  // c\n  \"a\": 1, // x, y\n  \"b\": [1, 2] /* z */\n}"},
+		{"[1, /* a */ 2 /* b */]", "[1, /* a */ 2 /* b */]"},
+		{"[1 // c\n 2]", "[1, // c\n 2]"},
+		{"[1, // c\n]", "[1 // c\n]"},
+		{"{\"a\" /* k */ : 1}", "{\"a\" /* k */ : 1}"},
+		{"[1 // c", "[1] // c\n"},
+		{"[1 /* c", "[1 /* c*/]"},
+		{"{\"a\": // c", "{\"a\": // c\nnull}"},
+		{"// h\n1\n2", "[\n// h\n1,\n2\n]"},
+	}
+
+	for _, tt := range ts {
+		result, err := JSONRepair(tt.Input, WithComments())
+		if err != nil {
+			t.Errorf("input: %q, err: %v", tt.Input, err)
+			continue
+		}
+		if result != tt.Expect {
+			t.Errorf("input: %q, expected: %q, got: %q", tt.Input, tt.Expect, result)
+		}
+	}
+}
+
+func TestRest(t *testing.T) {
+	ts := []struct {
+		Input      string
+		Output     string
+		Rest       string
+		RestOffset int
+	}{
+		{"{\"a\":1}\nLet me know if you need more!", "{\"a\":1}\n", "Let me know if you need more!", 8},
+		{`{"a":1}`, `{"a":1}`, ``, 7},
+		{`{"a":1}{"b":2}`, `{"a":1}`, `{"b":2}`, 7},
+		{`{"a": [1, 2`, `{"a": [1, 2]}`, ``, 11},
+		{`["ü"] rest`, `["ü"] `, `rest`, 7},
+	}
+	for _, tt := range ts {
+		res, err := Repair(tt.Input, WithRest())
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.Input, err)
+			continue
+		}
+		if res.Output != tt.Output || res.Rest != tt.Rest || res.RestOffset != tt.RestOffset || tt.Input[res.RestOffset:] != res.Rest {
+			t.Errorf("%q: expected %q, rest %q at %d, got %q, rest %q at %d", tt.Input, tt.Output, tt.Rest, tt.RestOffset, res.Output, res.Rest, res.RestOffset)
+		}
+	}
+
+	if _, err := Repair(``, WithRest()); err == nil {
+		t.Error("expected an error for an empty text")
+	}
+	res, err := Repair(`[{"a":1},{"b":2 tail`, WithRest(), WithTruncationPolicy(TruncationDrop))
+	if err != nil || res.Output != `[{"a":1}]` || res.Rest != "" || res.RestOffset != 20 {
+		t.Errorf("unexpected result %+v %v", res, err)
+	}
+}
@@ -0,0 +1,52 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+)
+
+// maxEncodings is the number of times the JSON text of a Lenient value may
+// have been encoded into a string.
+const maxEncodings = 3
+
+// Lenient holds a value of type T which is repaired when it is unmarshaled.
+// It allows a single field of an otherwise strict struct to be sloppy:
+//
+//	type Payload struct {
+//		ID     int                        `json:"id"`
+//		Config jsonrepair.Lenient[Config] `json:"config"`
+//	}
+//
+// Valid JSON is decoded into Value as is. Otherwise the JSON text is
+// repaired, and when it is a string which cannot be decoded into T, like
+// "{'a': 1,}", the content of the string is repaired and decoded instead.
+type Lenient[T any] struct {
+	Value T
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *Lenient[T]) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, &l.Value)
+	if err == nil {
+		return nil
+	}
+	text := string(data)
+	for i := 0; i < maxEncodings; i++ {
+		repaired, repairErr := JSONRepair(text)
+		if repairErr != nil {
+			return repairErr
+		}
+		if err = json.Unmarshal([]byte(repaired), &l.Value); err == nil {
+			return nil
+		}
+		// the text may be encoded into a string
+		if json.Unmarshal([]byte(repaired), &text) != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l Lenient[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Value)
+}
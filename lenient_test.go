@@ -0,0 +1,66 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLenient(t *testing.T) {
+	type config struct {
+		A int    `json:"a"`
+		B string `json:"b"`
+	}
+	type payload struct {
+		ID     int               `json:"id"`
+		Config Lenient[config]   `json:"config"`
+		Count  Lenient[int]      `json:"count"`
+		Name   Lenient[string]   `json:"name"`
+		Tags   Lenient[[]string] `json:"tags"`
+	}
+
+	ts := []struct {
+		Input  string
+		Expect payload
+	}{
+		{`{"id": 1, "config": {"a": 1, "b": "x"}, "count": 2, "name": "n", "tags": ["t"]}`,
+			payload{1, Lenient[config]{config{1, "x"}}, Lenient[int]{2}, Lenient[string]{"n"}, Lenient[[]string]{[]string{"t"}}}},
+		{`{"id": 2, "config": "{'a': 1, b: 'x',}", "count": "3", "name": "{a}", "tags": "[\"t\" \"u\"]"}`,
+			payload{2, Lenient[config]{config{1, "x"}}, Lenient[int]{3}, Lenient[string]{"{a}"}, Lenient[[]string]{[]string{"t", "u"}}}},
+		{`{"id": 3, "config": "\"{\\\"a\\\": 4\"", "tags": null}`,
+			payload{3, Lenient[config]{config{4, ""}}, Lenient[int]{}, Lenient[string]{}, Lenient[[]string]{}}},
+	}
+
+	for _, tt := range ts {
+		var p payload
+		if err := json.Unmarshal([]byte(tt.Input), &p); err != nil {
+			t.Errorf("input: %s, err: %v", tt.Input, err)
+			continue
+		}
+		if !reflect.DeepEqual(p, tt.Expect) {
+			t.Errorf("input: %s\nexpected: %+v\ngot:      %+v", tt.Input, tt.Expect, p)
+		}
+	}
+
+	var p payload
+	if err := json.Unmarshal([]byte(`{"count": "many"}`), &p); err == nil {
+		t.Errorf("expected an error")
+	}
+	if err := json.Unmarshal([]byte(`{"config": "{:}"}`), &p); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestLenientUnmarshalJSON(t *testing.T) {
+	var l Lenient[map[string]int]
+	if err := l.UnmarshalJSON([]byte(`{a: 1, 'b': 2`)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l.Value, map[string]int{"a": 1, "b": 2}) {
+		t.Errorf("unexpected value %v", l.Value)
+	}
+	data, err := json.Marshal(l)
+	if err != nil || string(data) != `{"a":1,"b":2}` {
+		t.Errorf("unexpected JSON %s, err %v", data, err)
+	}
+}
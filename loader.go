@@ -0,0 +1,119 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// FileError is an error at a line and column of a file, see LoadFile.
+type FileError struct {
+	Path string
+	// Line and Column are one-based, Column counts bytes. They are zero when
+	// the position of the error is unknown.
+	Line   int
+	Column int
+	Err    error
+}
+
+// NewFileError returns the error err of the text of the file at path, at the
+// given position in the text. A negative position is unknown.
+func NewFileError(path, text string, pos int, err error) *FileError {
+	e := &FileError{Path: path, Err: err}
+	if pos >= 0 {
+		p := OffsetPosition(text, pos, PositionEncodingUTF8)
+		e.Line, e.Column = p.Line+1, p.Character+1
+	}
+	return e
+}
+
+func (e *FileError) Error() string {
+	msg := e.Err.Error()
+	var repairErr JSONRepairError
+	if errors.As(e.Err, &repairErr) {
+		// the position is part of the location
+		msg = repairErr.Message
+	}
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, msg)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// LoadFile reads the JSON file at path, like a hand-edited configuration file
+// with comments and trailing commas, repairs it and unmarshals it into v, see
+// json.Unmarshal. Comments are always removed. An error in the file, when it
+// cannot be repaired or does not match v, is returned as a *FileError.
+func LoadFile(path string, v any, opts ...Option) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	text := string(data)
+	opts = append(opts[:len(opts):len(opts)], WithSourceMap(), func(o *options) {
+		o.comments = false
+	})
+	res, err := Repair(text, opts...)
+	if err != nil {
+		var repairErr JSONRepairError
+		if errors.As(err, &repairErr) {
+			return NewFileError(path, text, repairErr.Position, err)
+		}
+		return NewFileError(path, text, -1, err)
+	}
+	if err := json.Unmarshal([]byte(res.Output), v); err != nil {
+		pos := -1
+		var typeErr *json.UnmarshalTypeError
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.As(err, &typeErr):
+			start := typeErrorStart(res.Output, int(typeErr.Offset))
+			pos = res.SourceMap.InputOffset(utf8.RuneCountInString(res.Output[:start]))
+		case errors.As(err, &syntaxErr):
+			pos = res.SourceMap.InputOffset(utf8.RuneCountInString(res.Output[:syntaxErr.Offset]))
+		}
+		return NewFileError(path, text, pos, err)
+	}
+	return nil
+}
+
+// typeErrorStart returns the start of the value of an UnmarshalTypeError at the
+// given offset in the valid JSON text s. The offset of a string, number,
+// boolean or null is the end of the value, the offset of an object or array
+// is after its opening bracket.
+func typeErrorStart(s string, offset int) int {
+	if offset == 0 || offset > len(s) {
+		return offset
+	}
+	switch s[offset-1] {
+	case '{', '[':
+		return offset - 1
+	case '"':
+		// the start quote is the first one not escaped by a backslash
+		for i := offset - 2; i >= 0; i-- {
+			if s[i] != '"' {
+				continue
+			}
+			backslashes := 0
+			for i-backslashes > 0 && s[i-backslashes-1] == '\\' {
+				backslashes++
+			}
+			if backslashes%2 == 0 {
+				return i
+			}
+		}
+		return offset
+	}
+	i := offset
+	for i > 0 && !strings.ContainsRune(" \t\r\n,:[", rune(s[i-1])) {
+		i--
+	}
+	return i
+}
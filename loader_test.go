@@ -0,0 +1,64 @@
+package jsonrepair
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	type config struct {
+		Name  string `json:"name"`
+		Ports []int  `json:"ports"`
+	}
+	path := writeFile(t, "{\n  // the service\n  name: 'api',\n  ports: [80, 443,],\n}\n")
+	var c config
+	if err := LoadFile(path, &c, WithComments()); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "api" || len(c.Ports) != 2 || c.Ports[1] != 443 {
+		t.Errorf("unexpected config %+v", c)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	type config struct {
+		Port int `json:"port"`
+	}
+	cases := []struct {
+		text     string
+		expected string
+	}{
+		{"{\n  \"port\": 80,\n  :\n}", "config.json:3:3: Object key expected"},
+		{"{\n  // the port\n  \"port\": 'http'\n}", "config.json:3:11: json: cannot unmarshal string into Go struct field config.port of type int"},
+		{"{\n  \"port\": 'a\\'\"'\n}", "config.json:2:11: json: cannot unmarshal string into Go struct field config.port of type int"},
+		{"{\n  \"port\": 1.5,\n}", "config.json:2:11: json: cannot unmarshal number 1.5 into Go struct field config.port of type int"},
+		{"{\n  \"port\": {a: 1}\n}", "config.json:2:11: json: cannot unmarshal object into Go struct field config.port of type int"},
+	}
+	for _, c := range cases {
+		path := writeFile(t, c.text)
+		var v config
+		err := LoadFile(path, &v)
+		var fileErr *FileError
+		if !errors.As(err, &fileErr) {
+			t.Fatalf("%q: expected a FileError, got %v", c.text, err)
+		}
+		if got := fileErr.Error()[len(filepath.Dir(path))+1:]; got != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.text, c.expected, got)
+		}
+	}
+
+	if err := LoadFile(filepath.Join(t.TempDir(), "missing.json"), &struct{}{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
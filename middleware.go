@@ -0,0 +1,76 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type resultKey struct{}
+
+// Middleware returns a handler which repairs JSON request bodies, with
+// Content-Type application/json or a +json suffix, before passing the
+// request to next. The Content-Length of the request is updated, and the
+// Result of the repair is available to next through ResultFromContext. A
+// body which cannot be repaired, or which needs more repairs than allowed
+// by WithMaxRepairs, is rejected with status 400 Bad Request. A body larger
+// than WithMaxBodySize, or DefaultMaxBodySize, is rejected with status 413
+// Request Entity Too Large.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	o := options{maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody || !isJSONContentType(r.Header.Get("Content-Type")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, o.maxBodySize))
+		r.Body.Close()
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(bytes.TrimSpace(body)) == 0 {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+		res, err := Repair(string(body), opts...)
+		if err != nil {
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), resultKey{}, res))
+		r.Body = io.NopCloser(strings.NewReader(res.Output))
+		r.ContentLength = int64(len(res.Output))
+		r.Header.Set("Content-Length", strconv.Itoa(len(res.Output)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ResultFromContext returns the Result of the repair of the request body by
+// Middleware.
+func ResultFromContext(ctx context.Context) (*Result, bool) {
+	res, ok := ctx.Value(resultKey{}).(*Result)
+	return res, ok
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,84 @@
+package jsonrepair
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		repairs := -1
+		if res, ok := ResultFromContext(r.Context()); ok {
+			repairs = len(res.Repairs)
+		}
+		fmt.Fprintf(w, "%s %d %s %d", body, r.ContentLength, r.Header.Get("Content-Length"), repairs)
+	}), WithMaxRepairs(2))
+
+	ts := []struct {
+		ContentType string
+		Body        string
+		Status      int
+		Response    string
+	}{
+		{"application/json", `{"a":1}`, http.StatusOK, `{"a":1} 7 7 0`},
+		{"application/json; charset=utf-8", `{a:1,}`, http.StatusOK, `{"a":1} 7 7 2`},
+		{"application/merge-patch+json", `[1 2]`, http.StatusOK, `[1, 2] 6 6 1`},
+		{"text/plain", `{a:1,}`, http.StatusOK, `{a:1,} 6 6 -1`},
+		{"application/json", `{a:'b',}`, http.StatusBadRequest, "invalid JSON: Too many repairs at position 6\n"},
+		{"application/json", `{:}`, http.StatusBadRequest, "invalid JSON: Object key expected at position 1\n"},
+		{"application/json", ``, http.StatusOK, ` 0  -1`},
+	}
+
+	for _, tt := range ts {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.Body))
+		req.Header.Set("Content-Type", tt.ContentType)
+		if tt.Body != "" {
+			req.Header.Set("Content-Length", fmt.Sprint(len(tt.Body)))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.Status || rec.Body.String() != tt.Response {
+			t.Errorf("content type: %s, body: %s, expected %d %q, got %d %q", tt.ContentType, tt.Body, tt.Status, tt.Response, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestMiddlewareMaxBodySize(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}), WithMaxBodySize(8))
+	for body, status := range map[string]int{
+		`[1, 2]`:          http.StatusOK,
+		`[1, 2, 3, 4, 5]`: http.StatusRequestEntityTooLarge,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Errorf("body: %s, expected %d, got %d %q", body, status, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestMiddlewareServer(t *testing.T) {
+	server := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(`{"id": 1, "tags": ['a' 'b']`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"id": 1, "tags": ["a", "b"]}` {
+		t.Errorf("unexpected body %s", body)
+	}
+}
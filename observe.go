@@ -0,0 +1,108 @@
+package jsonrepair
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Observer receives statistics about repairs, see WithObserver.
+type Observer interface {
+	// ObserveRepair is called with the number of repairs of a kind applied
+	// to a repaired text, for every kind of repair the text needed.
+	ObserveRepair(kind RepairKind, count int)
+	// ObserveError is called when a text cannot be repaired, with the kind
+	// of the error, like "unexpected character", see ErrorKind.
+	ObserveError(kind string)
+	// ObserveDuration is called once for every text with the duration of
+	// its repair.
+	ObserveDuration(d time.Duration)
+}
+
+// errorKinds maps the start of the message of an error to its kind.
+var errorKinds = []struct{ prefix, kind string }{
+	{UnexpectedCharacterError.Message, "unexpected character"},
+	{ObjectKeyExpectedError.Message, "object key expected"},
+	{ColonExpectedError.Message, "colon expected"},
+	{UnexpectedEndError.Message, "unexpected end"},
+	{"Too many repairs", "too many repairs"},
+	{"Forbidden repair", "forbidden repair"},
+	{"Invalid unicode character", "invalid unicode character"},
+	{"Invalid number", "invalid number"},
+}
+
+// ErrorKind returns the kind of an error returned by the repair, for example
+// "unexpected character" for `Unexpected character "x" at position 7`. It
+// returns "other" for other errors.
+func ErrorKind(err error) string {
+	var repairErr JSONRepairError
+	if errors.As(err, &repairErr) {
+		for _, e := range errorKinds {
+			if strings.HasPrefix(repairErr.Message, e.prefix) {
+				return e.kind
+			}
+		}
+	}
+	return "other"
+}
+
+// observe reports a finished repair of a text of the given size in bytes,
+// which started at start and failed with err, to the logger of WithLogger
+// and the observer of WithObserver.
+func (t *RepairText) observe(size int, start time.Time, err error) {
+	if t.opts.logger == nil && t.opts.observer == nil {
+		return
+	}
+	duration := time.Since(start)
+
+	counts := map[RepairKind]int{}
+	for _, r := range t.repairs {
+		counts[r.Kind]++
+	}
+	if o := t.opts.observer; o != nil {
+		if err != nil {
+			o.ObserveError(ErrorKind(err))
+		}
+		for kind := RepairKind(0); err == nil && int(kind) < len(repairKindNames); kind++ {
+			if counts[kind] > 0 {
+				o.ObserveRepair(kind, counts[kind])
+			}
+		}
+		o.ObserveDuration(duration)
+	}
+	if t.opts.logger == nil {
+		return
+	}
+	var kinds []any
+	for kind := RepairKind(0); int(kind) < len(repairKindNames); kind++ {
+		if counts[kind] > 0 {
+			kinds = append(kinds, slog.Int(snakeCase(kind.String()), counts[kind]))
+		}
+	}
+	attrs := []slog.Attr{
+		slog.Int("size", size),
+		slog.Duration("duration", duration),
+		slog.Int("repairs", len(t.repairs)),
+		slog.Group("kinds", kinds...),
+	}
+
+	level, msg := slog.LevelDebug, "json valid"
+	if err != nil {
+		level, msg = slog.LevelWarn, "json repair failed"
+		attrs = append(attrs, slog.String("error", err.Error()))
+		var repairErr JSONRepairError
+		if errors.As(err, &repairErr) {
+			attrs = append(attrs, slog.Int("position", repairErr.Position))
+		}
+	} else if len(t.repairs) > 0 {
+		level, msg = slog.LevelInfo, "json repaired"
+	}
+	t.opts.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// snakeCase returns the name of a kind with underscores instead of spaces.
+func snakeCase(name string) string {
+	return strings.ReplaceAll(name, " ", "_")
+}
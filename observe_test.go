@@ -0,0 +1,120 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	JSONRepair(`{"a":1}`, WithLogger(logger))
+	JSONRepair(`{a:1, b:'x',}`, WithLogger(logger))
+	JSONRepair(`{:}`, WithLogger(logger))
+	dec := NewDecoder(strings.NewReader(`[1,] 2`), WithLogger(logger))
+	var v any
+	for dec.Decode(&v) == nil {
+	}
+
+	expected := `level=DEBUG msg="json valid" size=7 repairs=0
+level=INFO msg="json repaired" size=13 repairs=4 kinds.trailing_comma=1 kinds.non-standard_quotes=1 kinds.unquoted_key=2
+level=WARN msg="json repair failed" size=3 repairs=0 error="Object key expected at position 1" position=1
+level=INFO msg="json repaired" size=5 repairs=1 kinds.trailing_comma=1
+level=DEBUG msg="json valid" size=1 repairs=0
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) ObserveRepair(kind RepairKind, count int) {
+	o.events = append(o.events, fmt.Sprintf("repair %s %d", kind, count))
+}
+
+func (o *recordingObserver) ObserveError(kind string) {
+	o.events = append(o.events, "error "+kind)
+}
+
+func (o *recordingObserver) ObserveDuration(d time.Duration) {
+	o.events = append(o.events, "duration")
+}
+
+func TestWithObserver(t *testing.T) {
+	o := &recordingObserver{}
+	JSONRepair(`{"a":1}`, WithObserver(o))
+	JSONRepair(`{a:1, b:2,}`, WithObserver(o))
+	JSONRepair(`{:}`, WithObserver(o))
+	JSONRepair(`[1, 2-]`, WithObserver(o))
+
+	expected := []string{
+		"duration",
+		"repair trailing comma 1", "repair unquoted key 2", "duration",
+		"error object key expected", "duration",
+		"error invalid number", "duration",
+	}
+	if !reflect.DeepEqual(o.events, expected) {
+		t.Errorf("expected %q, got %q", expected, o.events)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	cases := map[string]string{
+		`[1,]x`:  "unexpected character",
+		`{:}`:    "object key expected",
+		`"\u26"`: "invalid unicode character",
+	}
+	for text, expected := range cases {
+		_, err := JSONRepair(text)
+		if kind := ErrorKind(err); kind != expected {
+			t.Errorf("%s: expected %q, got %q (%v)", text, expected, kind, err)
+		}
+	}
+	if kind := ErrorKind(errors.New("x")); kind != "other" {
+		t.Errorf("expected other, got %q", kind)
+	}
+}
+
+// expvarTests counts the runs of TestExpvarObserver, which publishes its
+// map under a new name every run.
+var expvarTests int
+
+func TestExpvarObserver(t *testing.T) {
+	expvarTests++
+	name := fmt.Sprintf("jsonrepair_test_%d", expvarTests)
+	o := NewExpvarObserver(name)
+	JSONRepair(`{a:1, b:2,}`, WithObserver(o))
+	JSONRepair(`{:}`, WithObserver(o))
+
+	v := expvar.Get(name).(*expvar.Map)
+	if calls := v.Get("calls").String(); calls != "2" {
+		t.Errorf("expected 2 calls, got %s", calls)
+	}
+	expected := `{"trailing_comma": 1, "unquoted_key": 2}`
+	if repairs := v.Get("repairs").String(); repairs != expected {
+		t.Errorf("expected %s, got %s", expected, repairs)
+	}
+	expected = `{"object_key_expected": 1}`
+	if errs := v.Get("errors").String(); errs != expected {
+		t.Errorf("expected %s, got %s", expected, errs)
+	}
+}
@@ -0,0 +1,143 @@
+package jsonrepair
+
+import "log/slog"
+
+// TruncationPolicy controls how an array element or object property which is
+// cut off by the end of the text is repaired.
+type TruncationPolicy int
+
+const (
+	// TruncationComplete completes the incomplete element, for example
+	// `[1,{"a":"b` is repaired into `[1,{"a":"b"}]`. This is the default.
+	TruncationComplete TruncationPolicy = iota
+	// TruncationDrop removes the incomplete element, for example
+	// `[1,{"a":"b` is repaired into `[1]`. The outermost incomplete array
+	// element is dropped, so that only fully received records are kept. When
+	// the text was not cut off inside an array, the innermost incomplete
+	// object property is dropped instead. The root value is never dropped.
+	TruncationDrop
+	// TruncationMark completes the incomplete element like
+	// TruncationComplete, and reports the element in Result.Truncated.
+	TruncationMark
+)
+
+type (
+	// Option configures the repair.
+	Option func(*options)
+
+	options struct {
+		truncation   TruncationPolicy
+		prefixStable bool
+		sourceMap    bool
+		comments     bool
+		// maxRepairs is the number of allowed repairs, or -1 when unlimited
+		maxRepairs int
+		forbidden  []RepairKind
+		weights    map[RepairKind]float64
+		bestEffort bool
+		logger     *slog.Logger
+		observer   Observer
+		// tokens records the tokens of the text, see Tokenize
+		tokens bool
+		// maxBodySize is the largest request body read by Middleware
+		maxBodySize int64
+		// firstValue stops the repair after the first root value, see
+		// Decoder and WithRest
+		firstValue bool
+	}
+)
+
+// WithTruncationPolicy sets how an element cut off by the end of the text is
+// repaired.
+func WithTruncationPolicy(policy TruncationPolicy) Option {
+	return func(o *options) {
+		o.truncation = policy
+	}
+}
+
+// WithPrefixStable makes the repair of a growing text stable: the first
+// Result.Stable runes of the output for a text are a prefix of the output for
+// any extension of that text. This is meant for rendering streamed JSON
+// without flicker. In this mode, a string is always ended at its first end
+// quote and newline delimited JSON is not repaired.
+func WithPrefixStable() Option {
+	return func(o *options) {
+		o.prefixStable = true
+	}
+}
+
+// WithComments keeps block and line comments in the output instead of
+// removing them, so the output is JSONC: all other repairs are applied, and
+// comments stay in place. An unterminated block comment is closed.
+func WithComments() Option {
+	return func(o *options) {
+		o.comments = true
+	}
+}
+
+// WithMaxRepairs makes the repair fail with TooManyRepairsError when the text
+// needs more than n repairs. With n zero or less, the text must be valid JSON.
+func WithMaxRepairs(n int) Option {
+	return func(o *options) {
+		o.maxRepairs = max0(n)
+	}
+}
+
+// WithForbiddenRepairs makes the repair fail with TooManyRepairsError when the
+// text needs a repair of one of the given kinds, like RepairUnquotedString
+// for a text which is not JSON at all.
+func WithForbiddenRepairs(kinds ...RepairKind) Option {
+	return func(o *options) {
+		o.forbidden = append(o.forbidden, kinds...)
+	}
+}
+
+// WithBestEffort makes the repair always succeed: characters which cannot be
+// repaired are skipped, or quoted into a string, and a missing value becomes
+// null. Every skipped part of the text is recorded as RepairSkippedText.
+func WithBestEffort() Option {
+	return func(o *options) {
+		o.bestEffort = true
+	}
+}
+
+// WithRest stops the repair after the first complete top-level value, and
+// returns the text after it in Result.Rest instead of failing, like the
+// explanation an LLM adds after a JSON document.
+func WithRest() Option {
+	return func(o *options) {
+		o.firstValue = true
+	}
+}
+
+// DefaultMaxBodySize is the largest request body Middleware reads when no
+// WithMaxBodySize option is given.
+const DefaultMaxBodySize = 10 << 20
+
+// WithMaxBodySize makes Middleware reject request bodies larger than n bytes
+// with status 413 Request Entity Too Large. It is ignored by the other
+// functions.
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) {
+		o.maxBodySize = n
+	}
+}
+
+// WithLogger logs a record for every repaired text, or value read by a
+// Decoder, to logger, with the size of the text in bytes, the duration, and
+// the number of repairs per kind. Texts which needed
+// repairs are logged at level Info, valid texts at level Debug, and texts
+// which cannot be repaired at level Warn.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithObserver reports every repaired text, or value read by a Decoder, to
+// observer.
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
@@ -0,0 +1,212 @@
+package jsonrepair
+
+import "sort"
+
+// outputBuffer holds repaired text, and remembers for every rune the position
+// in the text it originates from, or -1 for inserted runes.
+type outputBuffer struct {
+	runes []rune
+	// runs holds the sources of the runes as runs of runes originating from
+	// consecutive positions in the text, or of inserted runes, ordered by
+	// their index in the output
+	runs []sourceRun
+	// comment reports whether the text at the given position is part of a
+	// comment which is kept in the output, nil when comments are removed
+	comment func(source int) bool
+}
+
+// sourceRun is a run of runes in the output starting at index out, up to the
+// next run. The runes originate from the text starting at position in, or
+// are inserted when in is -1.
+type sourceRun struct {
+	out, in int
+}
+
+func newOutputBuffer() outputBuffer {
+	return outputBuffer{runes: []rune{}}
+}
+
+func (b *outputBuffer) String() string {
+	return string(b.runes)
+}
+
+func (b *outputBuffer) Len() int {
+	return len(b.runes)
+}
+
+// append adds runes originating from consecutive positions in the text
+// starting at from, or inserted runes when from is -1.
+func (b *outputBuffer) append(from int, runes ...rune) {
+	if len(runes) == 0 {
+		return
+	}
+	b.addRun(len(b.runes), from)
+	b.runes = append(b.runes, runes...)
+}
+
+func (b *outputBuffer) appendString(from int, s string) {
+	b.append(from, []rune(s)...)
+}
+
+func (b *outputBuffer) appendBuffer(o *outputBuffer) {
+	offset := len(b.runes)
+	for _, run := range o.runs {
+		b.addRun(offset+run.out, run.in)
+	}
+	b.runes = append(b.runes, o.runes...)
+}
+
+// addRun starts a run at the end of the output, unless it continues the last
+// run.
+func (b *outputBuffer) addRun(out, in int) {
+	if n := len(b.runs); n > 0 {
+		last := b.runs[n-1]
+		if last.in < 0 && in < 0 || last.in >= 0 && last.in+out-last.out == in {
+			return
+		}
+	}
+	b.runs = append(b.runs, sourceRun{out: out, in: in})
+}
+
+// run returns the index in runs of the run holding the rune at index.
+func (b *outputBuffer) run(index int) int {
+	return sort.Search(len(b.runs), func(i int) bool { return b.runs[i].out > index }) - 1
+}
+
+// source returns the position in the text the rune at index originates from,
+// or -1.
+func (b *outputBuffer) source(index int) int {
+	run := b.runs[b.run(index)]
+	if run.in < 0 {
+		return -1
+	}
+	return run.in + index - run.out
+}
+
+// sources returns the source of every rune of the output.
+func (b *outputBuffer) sources() []int {
+	sources := make([]int, len(b.runes))
+	for i, run := range b.runs {
+		end := len(b.runes)
+		if i+1 < len(b.runs) {
+			end = b.runs[i+1].out
+		}
+		for out := run.out; out < end; out++ {
+			if run.in < 0 {
+				sources[out] = -1
+			} else {
+				sources[out] = run.in + out - run.out
+			}
+		}
+	}
+	return sources
+}
+
+// split makes sure a run starts at index, and returns the index of that run
+// in runs.
+func (b *outputBuffer) split(index int) int {
+	i := sort.Search(len(b.runs), func(i int) bool { return b.runs[i].out >= index })
+	if i < len(b.runs) && b.runs[i].out == index || index == len(b.runes) {
+		return i
+	}
+	run := b.runs[i-1]
+	in := -1
+	if run.in >= 0 {
+		in = run.in + index - run.out
+	}
+	b.runs = append(b.runs[:i], append([]sourceRun{{out: index, in: in}}, b.runs[i:]...)...)
+	return i
+}
+
+// shift moves the runs starting with the run at index i by delta.
+func (b *outputBuffer) shift(i, delta int) {
+	for ; i < len(b.runs); i++ {
+		b.runs[i].out += delta
+	}
+}
+
+// insert inserts text at the given index.
+func (b *outputBuffer) insert(index int, text string) {
+	toInsert := []rune(text)
+	if len(toInsert) == 0 {
+		return
+	}
+	if index == len(b.runes) {
+		b.append(-1, toInsert...)
+		return
+	}
+	i := b.split(index)
+	b.shift(i, len(toInsert))
+	b.runs = append(b.runs[:i], append([]sourceRun{{out: index, in: -1}}, b.runs[i:]...)...)
+	b.runes = append(b.runes[:index], append(toInsert, b.runes[index:]...)...)
+}
+
+// insertBeforeLastWhitespace inserts text before the trailing whitespace and
+// comments, like InsertBeforeLastWhitespace, and returns the index of the
+// insertion.
+func (b *outputBuffer) insertBeforeLastWhitespace(text string) int {
+	index := len(b.runes)
+	for index > 0 && (IsWhitespace(b.runes[index-1]) || b.isComment(index-1)) {
+		index--
+	}
+	b.insert(index, text)
+	return index
+}
+
+// isComment returns whether the rune at index is part of a kept comment.
+func (b *outputBuffer) isComment(index int) bool {
+	if b.comment == nil {
+		return false
+	}
+	source := b.source(index)
+	return source >= 0 && b.comment(source)
+}
+
+// removeAt removes count runes starting at index.
+func (b *outputBuffer) removeAt(index, count int) {
+	if count == 0 {
+		return
+	}
+	i := b.split(index)
+	j := b.split(index + count)
+	b.shift(j, -count)
+	b.runs = append(b.runs[:i], b.runs[j:]...)
+	b.runes = append(b.runes[:index], b.runes[index+count:]...)
+}
+
+// stripLastOccurrence strips the last occurrence of r, and all text after it
+// when stripRemainingText is set. It returns the position in the text the
+// stripped rune originates from, or -1.
+func (b *outputBuffer) stripLastOccurrence(r rune, stripRemainingText bool) int {
+	for index := len(b.runes) - 1; index >= 0; index-- {
+		if b.runes[index] == r && !b.isComment(index) {
+			source := b.source(index)
+			if stripRemainingText {
+				b.removeAt(index, len(b.runes)-index)
+			} else {
+				b.removeAt(index, 1)
+			}
+			return source
+		}
+	}
+	return -1
+}
+
+// inputOffset returns the position in the text right after the last rune
+// before index which originates from the text.
+func (b *outputBuffer) inputOffset(index int) int {
+	if index == 0 {
+		return 0
+	}
+	for i := b.run(index - 1); i >= 0; i-- {
+		run := b.runs[i]
+		if run.in >= 0 {
+			last := index - 1
+			if i+1 < len(b.runs) && b.runs[i+1].out <= last {
+				last = b.runs[i+1].out - 1
+			}
+			return run.in + last - run.out + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package jsonrepair
+
+// PartialKind describes why a value in the repaired output is not final.
+type PartialKind int
+
+const (
+	// PartialString is a string, or unquoted symbol, without end quote.
+	PartialString PartialKind = iota
+	// PartialKey is an object key without end quote.
+	PartialKey
+	// PartialNumber is a number which may continue, or which has been
+	// completed with a digit, like `2.` into `2.0`.
+	PartialNumber
+	// PartialNull is a missing object value which has been filled with null.
+	PartialNull
+	// PartialArray is an array without closing bracket.
+	PartialArray
+	// PartialObject is an object without closing brace.
+	PartialObject
+)
+
+var partialKindNames = map[PartialKind]string{
+	PartialString: "string",
+	PartialKey:    "key",
+	PartialNumber: "number",
+	PartialNull:   "null",
+	PartialArray:  "array",
+	PartialObject: "object",
+}
+
+func (k PartialKind) String() string {
+	return partialKindNames[k]
+}
+
+// Partial is a value which has been synthesized or is still open because the
+// text ended before the value was complete.
+type Partial struct {
+	// Path is the JSON Pointer of the value in the repaired output.
+	Path string
+	Kind PartialKind
+}
+
+// markPartial records that the value being parsed is synthesized or still
+// open when the end of the text has been reached.
+func (t *RepairText) markPartial(kind PartialKind) {
+	if t.i >= len(t.text) {
+		t.truncated = true
+		t.partial = append(t.partial, Partial{Path: t.pointer(), Kind: kind})
+	}
+}
+
+// observeEnd records the length of the output which is final at the moment
+// the parser looks at the end of the text for the first time. Decisions made
+// after that moment may change when more text is appended.
+func (t *RepairText) observeEnd() {
+	t.endSeen = true
+	if t.opts.prefixStable && t.stable < 0 {
+		t.stable = stablePrefixLength(t.output.runes)
+	}
+}
+
+func (t *RepairText) stableLength() int {
+	if t.stable < 0 {
+		return t.output.Len()
+	}
+	return t.stable
+}
+
+// stablePrefixLength returns the length of output without the trailing
+// whitespace, comma and end quote, which a continuation of the text may
+// still modify: a missing comma is inserted before trailing whitespace, a
+// trailing comma is stripped, and concatenated strings are joined.
+func stablePrefixLength(output []rune) int {
+	output = trimTrailingWhitespace(output)
+	if len(output) > 0 && output[len(output)-1] == codeComma {
+		output = trimTrailingWhitespace(output[:len(output)-1])
+	}
+	if len(output) > 0 && output[len(output)-1] == codeDoubleQuote {
+		output = output[:len(output)-1]
+	}
+	return len(output)
+}
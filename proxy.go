@@ -0,0 +1,85 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ModifyResponse returns a hook for httputil.ReverseProxy.ModifyResponse,
+// which repairs JSON response bodies, with Content-Type application/json or
+// a +json suffix. Gzip encoded bodies are decoded and encoded again, bodies
+// with another Content-Encoding are passed unchanged. A chunked body is
+// replaced by a body with a Content-Length. When a body cannot be repaired,
+// the hook returns an error, on which the proxy responds with 502 Bad
+// Gateway.
+func ModifyResponse(opts ...Option) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		if resp.Body == nil || resp.Body == http.NoBody || !isJSONContentType(resp.Header.Get("Content-Type")) {
+			return nil
+		}
+		encoding := resp.Header.Get("Content-Encoding")
+		if encoding != "" && encoding != "gzip" {
+			return nil
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		text := body
+		if encoding == "gzip" {
+			if text, err = gunzip(body); err != nil {
+				return fmt.Errorf("jsonrepair: %w", err)
+			}
+		}
+		if len(bytes.TrimSpace(text)) == 0 {
+			return nil
+		}
+		res, err := Repair(string(text), opts...)
+		if err != nil {
+			return fmt.Errorf("jsonrepair: %w", err)
+		}
+		if res.Output == string(text) {
+			return nil
+		}
+
+		out := []byte(res.Output)
+		if encoding == "gzip" {
+			if out, err = gzipBytes(out); err != nil {
+				return fmt.Errorf("jsonrepair: %w", err)
+			}
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(out))
+		resp.ContentLength = int64(len(out))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+		resp.TransferEncoding = nil
+		return nil
+	}
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package jsonrepair
+
+import (
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+)
+
+func TestModifyResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/plain":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"a": 1,}`)
+		case "/valid":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"a": 1}`)
+		case "/gzip":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			io.WriteString(zw, `[1 2 3`)
+			zw.Close()
+		case "/chunked":
+			w.Header().Set("Content-Type", "application/problem+json")
+			io.WriteString(w, `{"title": 'a',`)
+			w.(http.Flusher).Flush()
+			io.WriteString(w, ` "status": 500}`)
+		case "/text":
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, `{"a": 1,}`)
+		case "/broken":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{:}`)
+		}
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ModifyResponse = ModifyResponse()
+	proxy.ErrorLog = log.New(io.Discard, "", 0)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	ts := []struct {
+		Path     string
+		Status   int
+		Body     string
+		Encoding string
+	}{
+		{"/plain", http.StatusOK, `{"a": 1}`, ""},
+		{"/valid", http.StatusOK, `{"a": 1}`, ""},
+		{"/gzip", http.StatusOK, `[1, 2, 3]`, "gzip"},
+		{"/chunked", http.StatusOK, `{"title": "a", "status": 500}`, ""},
+		{"/text", http.StatusOK, `{"a": 1,}`, ""},
+		{"/broken", http.StatusBadGateway, ``, ""},
+	}
+
+	for _, tt := range ts {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+tt.Path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body io.Reader = resp.Body
+		if resp.Header.Get("Content-Encoding") == "gzip" {
+			if body, err = gzip.NewReader(resp.Body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		data, _ := io.ReadAll(body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.Status || string(data) != tt.Body || resp.Header.Get("Content-Encoding") != tt.Encoding {
+			t.Errorf("path %s: expected %d %q %q, got %d %q %q", tt.Path, tt.Status, tt.Body, tt.Encoding, resp.StatusCode, data, resp.Header.Get("Content-Encoding"))
+		}
+		if resp.StatusCode == http.StatusOK && resp.ContentLength < 0 {
+			t.Errorf("path %s: expected a Content-Length", tt.Path)
+		}
+	}
+}
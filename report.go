@@ -0,0 +1,142 @@
+package jsonrepair
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RepairKind is the kind of issue a repair has fixed.
+type RepairKind int
+
+const (
+	RepairMissingComma RepairKind = iota
+	RepairTrailingComma
+	RepairMissingColon
+	RepairMissingValue
+	RepairMissingClosingBrace
+	RepairMissingClosingBracket
+	RepairRedundantClosingBracket
+	RepairUnquotedString
+	RepairMissingStartQuote
+	RepairMissingEndQuote
+	RepairQuotes
+	RepairEscapedString
+	RepairUnescapedQuote
+	RepairControlCharacter
+	RepairInvalidEscape
+	RepairSpecialWhitespace
+	RepairComment
+	RepairConcatenatedString
+	RepairLeadingZero
+	RepairTruncatedNumber
+	RepairPythonKeyword
+	RepairUndefined
+	RepairFunctionCall
+	RepairNewlineDelimited
+	RepairSkippedText
+	RepairUnquotedKey
+)
+
+var repairKindNames = map[RepairKind]string{
+	RepairMissingComma:            "missing comma",
+	RepairTrailingComma:           "trailing comma",
+	RepairMissingColon:            "missing colon",
+	RepairMissingValue:            "missing value",
+	RepairMissingClosingBrace:     "missing closing brace",
+	RepairMissingClosingBracket:   "missing closing bracket",
+	RepairRedundantClosingBracket: "redundant closing bracket",
+	RepairUnquotedString:          "unquoted string",
+	RepairMissingStartQuote:       "missing start quote",
+	RepairMissingEndQuote:         "missing end quote",
+	RepairQuotes:                  "non-standard quotes",
+	RepairEscapedString:           "escaped string",
+	RepairUnescapedQuote:          "unescaped quote",
+	RepairControlCharacter:        "unescaped control character",
+	RepairInvalidEscape:           "invalid escape character",
+	RepairSpecialWhitespace:       "special whitespace",
+	RepairComment:                 "comment",
+	RepairConcatenatedString:      "concatenated string",
+	RepairLeadingZero:             "number with leading zero",
+	RepairTruncatedNumber:         "truncated number",
+	RepairPythonKeyword:           "python keyword",
+	RepairUndefined:               "undefined",
+	RepairFunctionCall:            "function call",
+	RepairNewlineDelimited:        "newline delimited json",
+	RepairSkippedText:             "skipped text",
+	RepairUnquotedKey:             "unquoted key",
+}
+
+func (k RepairKind) String() string {
+	return repairKindNames[k]
+}
+
+// RepairAction is a single repair applied to the text.
+type RepairAction struct {
+	Kind RepairKind
+	// Start and End are the positions of the repaired part of the text. They
+	// are equal when the repair only inserted text.
+	Start int
+	End   int
+}
+
+// report records a repair of the text between start and end.
+func (t *RepairText) report(kind RepairKind, start, end int) {
+	t.repairs = append(t.repairs, RepairAction{
+		Kind:  kind,
+		Start: min(start, len(t.text)),
+		End:   min(end, len(t.text)),
+	})
+}
+
+// reportInsert records a repair which inserted text at the given index of
+// buffer.
+func (t *RepairText) reportInsert(kind RepairKind, buffer *outputBuffer, index int) {
+	pos := buffer.inputOffset(index)
+	t.report(kind, pos, pos)
+}
+
+// sortedRepairs returns the recorded repairs in the order of the text.
+func (t *RepairText) sortedRepairs() []RepairAction {
+	repairs := append([]RepairAction{}, t.repairs...)
+	sort.SliceStable(repairs, func(i, j int) bool {
+		return repairs[i].Start < repairs[j].Start
+	})
+	return repairs
+}
+
+// reportTrailingComma records the repair of a stripped comma which originates
+// from the given position in the text. A stripped comma which has been
+// inserted before was not missing after all.
+func (t *RepairText) reportTrailingComma(source int) {
+	t.stripToken(source)
+	if source >= 0 {
+		t.report(RepairTrailingComma, source, source+1)
+		return
+	}
+	for i := len(t.repairs) - 1; i >= 0; i-- {
+		if t.repairs[i].Kind == RepairMissingComma {
+			t.repairs = append(t.repairs[:i], t.repairs[i+1:]...)
+			t.flushed = min(t.flushed, len(t.repairs))
+			return
+		}
+	}
+}
+
+// checkRepairs returns a TooManyRepairsError when the repairs exceed
+// WithMaxRepairs or include a kind of WithForbiddenRepairs.
+func (t *RepairText) checkRepairs() error {
+	repairs := t.sortedRepairs()
+	for _, r := range repairs {
+		for _, kind := range t.opts.forbidden {
+			if r.Kind == kind {
+				msg := fmt.Sprintf("Forbidden repair %q", kind)
+				return TooManyRepairsError{NewJSONRepairError(msg).At(r.Start), t.result()}
+			}
+		}
+	}
+	if limit := t.opts.maxRepairs; limit >= 0 && len(repairs) > limit {
+		err := NewJSONRepairError("Too many repairs").At(repairs[limit].Start)
+		return TooManyRepairsError{err, t.result()}
+	}
+	return nil
+}
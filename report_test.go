@@ -0,0 +1,105 @@
+package jsonrepair
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRepairs(t *testing.T) {
+	ts := []struct {
+		Input   string
+		Repairs []RepairAction
+	}{
+		{`{"a":1}`, []RepairAction{}},
+		{`{"a":1,}`, []RepairAction{{RepairTrailingComma, 6, 7}}},
+		{`[1 2]`, []RepairAction{{RepairMissingComma, 2, 2}}},
+		{`{a:'b'}`, []RepairAction{{RepairUnquotedKey, 1, 2}, {RepairQuotes, 3, 6}}},
+		{`{"a" 1 // c`, []RepairAction{{RepairMissingColon, 4, 4}, {RepairMissingClosingBrace, 6, 6}, {RepairComment, 7, 11}}},
+		{`{"a":`, []RepairAction{{RepairMissingValue, 5, 5}, {RepairMissingClosingBrace, 5, 5}}},
+		{`["ab`, []RepairAction{{RepairMissingEndQuote, 4, 4}, {RepairMissingClosingBracket, 4, 4}}},
+		{`[a","b"]`, []RepairAction{{RepairMissingStartQuote, 1, 3}}},
+		{`{"a":"foo "bar" baz"}`, []RepairAction{{RepairMissingComma, 11, 11}, {RepairMissingStartQuote, 11, 15}, {RepairMissingColon, 14, 14}, {RepairMissingStartQuote, 16, 20}}},
+		{`'foo "bar"'`, []RepairAction{{RepairQuotes, 0, 11}, {RepairUnescapedQuote, 5, 6}, {RepairUnescapedQuote, 9, 10}}},
+		{"[True, None, undefined, 007]", []RepairAction{{RepairPythonKeyword, 1, 5}, {RepairPythonKeyword, 7, 11}, {RepairUndefined, 13, 22}, {RepairLeadingZero, 24, 27}}},
+		{"1\n2", []RepairAction{{RepairNewlineDelimited, 0, 3}, {RepairMissingComma, 1, 1}}},
+		{`[1,]]`, []RepairAction{{RepairTrailingComma, 2, 3}, {RepairRedundantClosingBracket, 4, 5}}},
+		{`"a" + "b"`, []RepairAction{{RepairConcatenatedString, 4, 9}}},
+		{`callback({})`, []RepairAction{{RepairFunctionCall, 0, 12}}},
+		{`{"a": x&y "b": 1}`, []RepairAction{{RepairUnquotedString, 6, 9}, {RepairMissingComma, 9, 9}}},
+		{`{"a":\1}`, []RepairAction{{RepairSkippedText, 5, 6}}},
+	}
+
+	for _, tt := range ts {
+		res, err := Repair(tt.Input)
+		if err != nil {
+			t.Errorf("case: %s, err: %v", tt.Input, err)
+			continue
+		}
+		if fmt.Sprint(res.Repairs) != fmt.Sprint(tt.Repairs) {
+			t.Errorf("case: %s, got: %v, expect: %v", tt.Input, res.Repairs, tt.Repairs)
+		}
+	}
+}
+
+func TestMaxRepairs(t *testing.T) {
+	if _, err := Repair(`{a:1}`, WithMaxRepairs(1)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	_, err := Repair(`{a:1, b:2}`, WithMaxRepairs(1))
+	if err == nil || err.Error() != "Too many repairs at position 6" {
+		t.Errorf("unexpected error %v", err)
+	}
+	var tooMany TooManyRepairsError
+	if !errors.As(err, &tooMany) || len(tooMany.Result.Repairs) != 2 || tooMany.Result.Output != `{"a":1, "b":2}` {
+		t.Errorf("expected the rejected result, got %v", err)
+	}
+	var repairErr JSONRepairError
+	if !errors.As(err, &repairErr) || repairErr.Position != 6 {
+		t.Errorf("expected a JSONRepairError, got %v", err)
+	}
+	for _, n := range []int{0, -1} {
+		if _, err := Repair(`{"a":1}`, WithMaxRepairs(n)); err != nil {
+			t.Errorf("%d: unexpected error %v", n, err)
+		}
+		if _, err := Repair(`{"a":1,}`, WithMaxRepairs(n)); err == nil || err.Error() != "Too many repairs at position 6" {
+			t.Errorf("%d: unexpected error %v", n, err)
+		}
+	}
+
+	var v any
+	dec := NewDecoder(strings.NewReader(`{"a":1} {a:1,b:2,c:3,}`), WithMaxRepairs(0))
+	if err := dec.Decode(&v); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := dec.Decode(&v); !errors.As(err, &tooMany) || err.Error() != "Too many repairs at position 1" {
+		t.Errorf("expected too many repairs, got %v", err)
+	}
+}
+
+func TestForbiddenRepairs(t *testing.T) {
+	opt := WithForbiddenRepairs(RepairUnquotedString, RepairMissingClosingBracket)
+	if _, err := Repair(`[1, 2,]`, opt); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := Repair(`{a: 1}`, opt); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	_, err := Repair(`[1, [2, abc`, opt)
+	if err == nil || err.Error() != `Forbidden repair "unquoted string" at position 8` {
+		t.Errorf("unexpected error %v", err)
+	}
+	var tooMany TooManyRepairsError
+	if !errors.As(err, &tooMany) || tooMany.Result.Output != `[1, [2, "abc"]]` {
+		t.Errorf("expected the rejected result, got %v", err)
+	}
+	if kind := ErrorKind(err); kind != "forbidden repair" {
+		t.Errorf("expected forbidden repair, got %q", kind)
+	}
+
+	var v any
+	if err := NewDecoder(strings.NewReader(`[1, [2, abc`), opt).Decode(&v); !errors.As(err, &tooMany) {
+		t.Errorf("expected a forbidden repair, got %v", err)
+	}
+}
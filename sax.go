@@ -0,0 +1,77 @@
+package jsonrepair
+
+// Handler receives the events of Walk. Embed BaseHandler to implement only
+// the events of interest.
+type Handler interface {
+	OnObjectStart()
+	OnObjectEnd()
+	OnArrayStart()
+	OnArrayEnd()
+	// OnKey is called with the key of an object member, before the events of
+	// its value.
+	OnKey(key string)
+	// OnValue is called with a string, number, boolean or null, and its
+	// repaired JSON text, for example NodeString and `"b"` for the text `'b'`.
+	OnValue(kind NodeKind, value string)
+	// OnRepair is called with a repair before the event of the value it
+	// applies to.
+	OnRepair(repair RepairAction)
+}
+
+// BaseHandler implements Handler, ignoring all events.
+type BaseHandler struct{}
+
+func (BaseHandler) OnObjectStart()           {}
+func (BaseHandler) OnObjectEnd()             {}
+func (BaseHandler) OnArrayStart()            {}
+func (BaseHandler) OnArrayEnd()              {}
+func (BaseHandler) OnKey(string)             {}
+func (BaseHandler) OnValue(NodeKind, string) {}
+func (BaseHandler) OnRepair(RepairAction)    {}
+
+// Walk repairs the text and passes the structure of the repaired document to
+// the handler while parsing, instead of returning the repaired text. The
+// events of a value are passed once the value is final, so they are never
+// revoked. Newline delimited JSON is passed as a sequence of root values,
+// without enclosing array. When the text cannot be repaired, the events up to
+// the position of the error have been passed before the error is returned.
+func Walk(text string, h Handler) error {
+	t := newRepairText(text, nil)
+	t.handler = h
+	if err := t.repair(); err != nil {
+		return err
+	}
+	t.flushRepairs()
+	return nil
+}
+
+// emit passes an event to the handler of Walk, after the repairs reported
+// since the previous event.
+func (t *RepairText) emit(event func(Handler)) {
+	if t.handler == nil {
+		return
+	}
+	t.flushRepairs()
+	event(t.handler)
+	t.handled++
+}
+
+// emitValue passes the value written to the output starting at the given
+// output position. Nothing is passed when no value has been written, like for
+// a function call without argument.
+func (t *RepairText) emitValue(start int) {
+	if t.handler == nil {
+		return
+	}
+	value := t.outputValue(start)
+	if value == "" {
+		return
+	}
+	t.emit(func(h Handler) { h.OnValue(valueKind(value), value) })
+}
+
+func (t *RepairText) flushRepairs() {
+	for ; t.flushed < len(t.repairs); t.flushed++ {
+		t.handler.OnRepair(t.repairs[t.flushed])
+	}
+}
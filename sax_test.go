@@ -0,0 +1,80 @@
+package jsonrepair
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type recordingHandler struct {
+	events []string
+}
+
+func (h *recordingHandler) OnObjectStart()   { h.events = append(h.events, "{") }
+func (h *recordingHandler) OnObjectEnd()     { h.events = append(h.events, "}") }
+func (h *recordingHandler) OnArrayStart()    { h.events = append(h.events, "[") }
+func (h *recordingHandler) OnArrayEnd()      { h.events = append(h.events, "]") }
+func (h *recordingHandler) OnKey(key string) { h.events = append(h.events, "key "+key) }
+func (h *recordingHandler) OnValue(kind NodeKind, value string) {
+	h.events = append(h.events, kind.String()+" "+value)
+}
+func (h *recordingHandler) OnRepair(repair RepairAction) {
+	h.events = append(h.events, fmt.Sprintf("repair %v %d-%d", repair.Kind, repair.Start, repair.End))
+}
+
+func TestWalk(t *testing.T) {
+	ts := []struct {
+		Input  string
+		Events string
+	}{
+		{`{"a": [1, "b", true, null]}`, `{|key a|[|number 1|string "b"|boolean true|null null|]|}`},
+		{`{a: 'b', c: [1 2,],}`, `{|repair unquoted key 1-2|key a|repair non-standard quotes 4-7|string "b"|repair unquoted key 9-10|key c|[|number 1|repair missing comma 14-14|number 2|repair trailing comma 16-17|]|repair trailing comma 18-19|}`},
+		{`{"a":`, `{|key a|repair missing value 5-5|null null|repair missing closing brace 5-5|}`},
+		{`callback([None]);`, `[|repair python keyword 10-14|null null|]|repair function call 0-17`},
+		{"1\n\"x\"\n{}", `number 1|repair missing comma 1-1|string "x"|repair missing comma 5-5|{|}|repair newline delimited json 0-8`},
+		{`"a" + "b"`, `repair concatenated string 4-9|string "ab"`},
+		{`[1,]]`, `[|number 1|repair trailing comma 2-3|]|repair redundant closing bracket 4-5`},
+		{`callback();`, `repair function call 0-11`},
+		{`f()`, `repair function call 0-3`},
+		{`[f(]`, `[|repair function call 1-3|]`},
+	}
+
+	for _, tt := range ts {
+		h := &recordingHandler{}
+		if err := Walk(tt.Input, h); err != nil {
+			t.Errorf("input: %q, err: %v", tt.Input, err)
+			continue
+		}
+		if got := strings.Join(h.events, "|"); got != tt.Events {
+			t.Errorf("input: %q\nexpected: %s\ngot:      %s", tt.Input, tt.Events, got)
+		}
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	h := &recordingHandler{}
+	err := Walk(`[1, {:2}]`, h)
+	if err == nil || err.Error() != "Object key expected at position 5" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got := strings.Join(h.events, "|"); got != "[|number 1|{" {
+		t.Errorf("unexpected events %s", got)
+	}
+}
+
+func TestBaseHandler(t *testing.T) {
+	h := &countingHandler{}
+	if err := Walk(`[1, 2, {"a": 3}]`, h); err != nil {
+		t.Fatal(err)
+	}
+	if h.values != 3 {
+		t.Errorf("expected 3 values, got %d", h.values)
+	}
+}
+
+type countingHandler struct {
+	BaseHandler
+	values int
+}
+
+func (h *countingHandler) OnValue(NodeKind, string) { h.values++ }
@@ -0,0 +1,86 @@
+package jsonrepair
+
+import (
+	"sort"
+)
+
+type (
+	// SourceMap maps positions in the repaired output to positions in the
+	// original text and back. Positions count runes, like the Position of a
+	// JSONRepairError.
+	SourceMap struct {
+		// Segments are the parts of the output which originate from the
+		// text, sorted by output and by input offset. Output which has been
+		// inserted by the repair is not covered by a segment.
+		Segments []Segment
+	}
+
+	// Segment maps the output runes [Output, Output+Length) to the text runes
+	// [Input, Input+Length).
+	Segment struct {
+		Output int
+		Input  int
+		Length int
+	}
+)
+
+// WithSourceMap makes Repair return a SourceMap in Result.SourceMap.
+func WithSourceMap() Option {
+	return func(o *options) {
+		o.sourceMap = true
+	}
+}
+
+func newSourceMap(source []int) *SourceMap {
+	m := &SourceMap{Segments: []Segment{}}
+	for out, in := range source {
+		if in < 0 {
+			continue
+		}
+		if n := len(m.Segments); n > 0 {
+			last := &m.Segments[n-1]
+			if last.Output+last.Length == out && last.Input+last.Length == in {
+				last.Length++
+				continue
+			}
+		}
+		m.Segments = append(m.Segments, Segment{Output: out, Input: in, Length: 1})
+	}
+	return m
+}
+
+// InputOffset returns the position in the text of the given output position.
+// Output inserted by the repair maps to the position in the text where it has
+// been inserted.
+func (m *SourceMap) InputOffset(out int) int {
+	// the last segment starting at or before out
+	i := sort.Search(len(m.Segments), func(i int) bool {
+		return m.Segments[i].Output > out
+	}) - 1
+	if i < 0 {
+		return 0
+	}
+	segment := m.Segments[i]
+	if out < segment.Output+segment.Length {
+		return segment.Input + out - segment.Output
+	}
+	return segment.Input + segment.Length
+}
+
+// OutputOffset returns the position in the output of the given position in
+// the text. Text removed by the repair, like comments, maps to the position in
+// the output where it has been removed.
+func (m *SourceMap) OutputOffset(in int) int {
+	// the last segment starting at or before in
+	i := sort.Search(len(m.Segments), func(i int) bool {
+		return m.Segments[i].Input > in
+	}) - 1
+	if i < 0 {
+		return 0
+	}
+	segment := m.Segments[i]
+	if in < segment.Input+segment.Length {
+		return segment.Output + in - segment.Input
+	}
+	return segment.Output + segment.Length
+}
@@ -0,0 +1,102 @@
+package jsonrepair
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSourceMap(t *testing.T) {
+	ts := []struct {
+		Input  string
+		Want   string
+		Output []int // input offset for every output rune
+	}{
+		{`{"a":1}`, `{"a":1}`, []int{0, 1, 2, 3, 4, 5, 6}},
+		{`{'a':1`, `{"a":1}`, []int{0, 1, 2, 3, 4, 5, 6}},
+		{`[1,/*x*/2]`, `[1,2]`, []int{0, 1, 2, 8, 9}},
+		{`{a:2}`, `{"a":2}`, []int{0, 1, 1, 2, 2, 3, 4}},
+		{`[1 2]`, `[1, 2]`, []int{0, 1, 2, 2, 3, 4}},
+		{`"a" + "b"`, `"ab"`, []int{0, 1, 7, 8}},
+		{`{"a":`, `{"a":null}`, []int{0, 1, 2, 3, 4, 5, 5, 5, 5, 5}},
+		{`[x&y, 1]`, `["x\u0026y", 1]`, []int{0, 1, 1, 2, 2, 2, 2, 2, 2, 3, 4, 4, 5, 6, 7}},
+	}
+
+	for _, tt := range ts {
+		res, err := Repair(tt.Input, WithSourceMap())
+		if err != nil {
+			t.Errorf("case: %s, err: %v", tt.Input, err)
+			continue
+		}
+		if res.Output != tt.Want {
+			t.Errorf("case: %s, got: %s, expect: %s", tt.Input, res.Output, tt.Want)
+			continue
+		}
+		for out, in := range tt.Output {
+			if got := res.SourceMap.InputOffset(out); got != in {
+				t.Errorf("case: %s, input offset of %d is %d, expect: %d", tt.Input, out, got, in)
+			}
+		}
+	}
+}
+
+func TestSourceMapOutputOffset(t *testing.T) {
+	res, err := Repair("[1,/*x*/2, 'b']", WithSourceMap())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// [1,2, "b"]
+	for in, out := range []int{0, 1, 2, 3, 3, 3, 3, 3, 3, 4, 5, 6, 7, 8, 9} {
+		if got := res.SourceMap.OutputOffset(in); got != out {
+			t.Errorf("output offset of %d is %d, expect: %d", in, got, out)
+		}
+	}
+}
+
+func TestSourceMapKeepsOutput(t *testing.T) {
+	inputs := []string{
+		`{a:'b', c: [1 2 3,], d: "x" + "y", e: NumberLong("2"), f: 007, g: undefined}`,
+		"/* 1 */\n{}\n/* 2 */\n{},\n",
+		`{"a":"b★c\\d\"e` + "\n\tf",
+		`[1,2,3`,
+	}
+	for _, input := range inputs {
+		want, err := JSONRepair(input)
+		if err != nil {
+			t.Errorf("case: %s, err: %v", input, err)
+			continue
+		}
+		tr := newRepairText(input, []Option{WithSourceMap()})
+		if err := tr.repair(); err != nil {
+			t.Errorf("case: %s, err: %v", input, err)
+			continue
+		}
+		if tr.output.String() != want || len(tr.output.sources()) != tr.output.Len() {
+			t.Errorf("case: %s, got: %s with %d sources, expect: %s", input, tr.output.String(), len(tr.output.sources()), want)
+		}
+	}
+}
+
+func TestOutputBufferSources(t *testing.T) {
+	var b outputBuffer
+	b.appendString(0, "[1,")
+	b.appendString(4, "2 ")
+	b.insertBeforeLastWhitespace(",")
+	b.appendString(7, "3]")
+	b.insert(0, "x")
+	b.removeAt(2, 3)
+	tmp := newOutputBuffer()
+	tmp.appendString(9, "ab")
+	tmp.appendString(-1, "c")
+	b.appendBuffer(&tmp)
+	b.stripLastOccurrence('b', true)
+
+	want := []int{-1, 0, -1, 5, 7, 8, 9}
+	if b.String() != "x[, 3]a" || !reflect.DeepEqual(b.sources(), want) {
+		t.Errorf("got: %s %v, expect: x[, 3]a %v", b.String(), b.sources(), want)
+	}
+	for index, offset := range []int{0, 0, 1, 1, 6, 8, 9, 10} {
+		if got := b.inputOffset(index); got != offset {
+			t.Errorf("inputOffset(%d) got: %d, expect: %d", index, got, offset)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package jsonrepair
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON is a JSON document in a database column, which is repaired when it
+// is scanned. It implements sql.Scanner and driver.Valuer.
+type JSON struct {
+	// Raw is the repaired document.
+	Raw json.RawMessage
+	// Repaired is set when the scanned document needed a repair.
+	Repaired bool
+	// Valid is set when the column is not NULL.
+	Valid bool
+}
+
+// Scan implements sql.Scanner.
+func (j *JSON) Scan(src any) error {
+	text, valid, err := scanText(src)
+	if err != nil || !valid {
+		*j = JSON{}
+		return err
+	}
+	repaired, err := JSONRepair(text)
+	if err != nil {
+		return err
+	}
+	*j = JSON{Raw: json.RawMessage(repaired), Repaired: repaired != text, Valid: true}
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (j JSON) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+	return string(j.Raw), nil
+}
+
+// JSONOf is a JSON document in a database column like JSON, which is decoded
+// into a value of type T.
+type JSONOf[T any] struct {
+	V T
+	// Repaired is set when the scanned document needed a repair.
+	Repaired bool
+	// Valid is set when the column is not NULL.
+	Valid bool
+}
+
+// Scan implements sql.Scanner.
+func (j *JSONOf[T]) Scan(src any) error {
+	var doc JSON
+	if err := doc.Scan(src); err != nil {
+		return err
+	}
+	var v T
+	if doc.Valid {
+		if err := json.Unmarshal(doc.Raw, &v); err != nil {
+			return err
+		}
+	}
+	*j = JSONOf[T]{V: v, Repaired: doc.Repaired, Valid: doc.Valid}
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (j JSONOf[T]) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+	data, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// scanText returns the text of a scanned column, and whether it is not NULL.
+func scanText(src any) (string, bool, error) {
+	switch src := src.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return src, true, nil
+	case []byte:
+		return string(src), true, nil
+	default:
+		return "", false, fmt.Errorf("jsonrepair: cannot scan %T into JSON", src)
+	}
+}
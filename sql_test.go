@@ -0,0 +1,133 @@
+package jsonrepair
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// stubDriver is an in-memory database with a single table of one column.
+// Every statement with arguments inserts a row, and every statement without
+// arguments selects all rows.
+type stubDriver struct {
+	rows []driver.Value
+}
+
+type stubConn struct{ d *stubDriver }
+type stubStmt struct{ d *stubDriver }
+type stubRows struct {
+	rows []driver.Value
+}
+
+func (d *stubDriver) Open(string) (driver.Conn, error) { return &stubConn{d}, nil }
+
+// Connect and Driver make the driver its own connector, so that every test
+// opens a new database without registering a driver.
+func (d *stubDriver) Connect(context.Context) (driver.Conn, error) { return &stubConn{d}, nil }
+func (d *stubDriver) Driver() driver.Driver                        { return d }
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) { return &stubStmt{c.d}, nil }
+func (c *stubConn) Close() error                        { return nil }
+func (c *stubConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return -1 }
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.rows = append(s.d.rows, args...)
+	return driver.RowsAffected(len(args)), nil
+}
+func (s *stubStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &stubRows{rows: s.d.rows}, nil
+}
+
+func (r *stubRows) Columns() []string { return []string{"doc"} }
+func (r *stubRows) Close() error      { return nil }
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.rows[0]
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQL(t *testing.T) {
+	stub := &stubDriver{}
+	db := sql.OpenDB(stub)
+	defer db.Close()
+
+	type doc struct {
+		A int `json:"a"`
+	}
+	for _, arg := range []any{
+		`{"a": 1}`,
+		[]byte(`{a: 2,}`),
+		nil,
+		JSON{Raw: []byte(`{"a":3}`), Valid: true},
+		JSONOf[doc]{V: doc{4}, Valid: true},
+		JSONOf[doc]{},
+	} {
+		if _, err := db.Exec("insert", arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if expected := []driver.Value{`{"a": 1}`, []byte(`{a: 2,}`), nil, `{"a":3}`, `{"a":4}`, nil}; !reflect.DeepEqual(stub.rows, expected) {
+		t.Errorf("expected rows %q, got %q", expected, stub.rows)
+	}
+
+	rows, err := db.Query("select")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []JSONOf[doc]
+	for rows.Next() {
+		var d JSONOf[doc]
+		if err := rows.Scan(&d); err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, d)
+	}
+	expected := []JSONOf[doc]{
+		{V: doc{1}, Valid: true},
+		{V: doc{2}, Repaired: true, Valid: true},
+		{},
+		{V: doc{3}, Valid: true},
+		{V: doc{4}, Valid: true},
+		{},
+	}
+	if !reflect.DeepEqual(docs, expected) {
+		t.Errorf("expected %+v, got %+v", expected, docs)
+	}
+
+	var j JSON
+	if err := db.QueryRow("select").Scan(&j); err != nil {
+		t.Fatal(err)
+	}
+	if string(j.Raw) != `{"a": 1}` || j.Repaired || !j.Valid {
+		t.Errorf("unexpected document %+v", j)
+	}
+}
+
+func TestJSONScan(t *testing.T) {
+	var j JSON
+	if err := j.Scan("[1, 2"); err != nil || string(j.Raw) != "[1, 2]" || !j.Repaired || !j.Valid {
+		t.Errorf("unexpected document %+v, err %v", j, err)
+	}
+	if err := j.Scan(nil); err != nil || j.Valid || j.Raw != nil {
+		t.Errorf("unexpected document %+v, err %v", j, err)
+	}
+	if err := j.Scan(42); err == nil {
+		t.Errorf("expected an error")
+	}
+	if err := j.Scan("{:}"); err == nil {
+		t.Errorf("expected an error")
+	}
+	var n JSONOf[int]
+	if err := n.Scan(`"x"`); err == nil {
+		t.Errorf("expected an error")
+	}
+}
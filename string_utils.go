@@ -2,7 +2,6 @@ package jsonrepair
 
 import (
 	"regexp"
-	"strings"
 )
 
 const (
@@ -163,21 +162,6 @@ func isSingleQuote(code rune) bool {
 	return code == codeQuote
 }
 
-/**
- * Strip last occurrence of textToStrip from text
- */
-func stripLastOccurrence(text, textToStrip string, stripRemainingText bool) string {
-	index := strings.LastIndex(text, textToStrip)
-	if index != -1 {
-		if stripRemainingText {
-			return text[:index]
-		} else {
-			return text[:index] + text[index+1:]
-		}
-	}
-	return text
-}
-
 func InsertBeforeLastWhitespace(text []rune, textToInsert string) []rune {
 	index := len(text)
 	toInsert := []rune(textToInsert)
@@ -220,3 +204,11 @@ func nextNonWhiteSpaceCharacter(text []rune, start int) rune {
 	}
 	return text[i]
 }
+
+func trimTrailingWhitespace(text []rune) []rune {
+	end := len(text)
+	for end > 0 && IsWhitespace(text[end-1]) {
+		end--
+	}
+	return text[:end]
+}
@@ -0,0 +1,176 @@
+package jsonrepair
+
+import "sort"
+
+// TokenKind is the lexical class of a Token.
+type TokenKind int
+
+const (
+	// TokenPunctuation is one of `{ } [ ] : ,`, or the `+ ( ) ;` of string
+	// concatenation and function calls.
+	TokenPunctuation TokenKind = iota
+	// TokenString is a quoted string, including its quotes.
+	TokenString
+	// TokenNumber is a number.
+	TokenNumber
+	// TokenKeyword is one of true, false, null, or their Python variants.
+	TokenKeyword
+	// TokenSymbol is an unquoted string, like a key without quotes, undefined
+	// or the name of a function call.
+	TokenSymbol
+	// TokenComment is a line or block comment.
+	TokenComment
+	// TokenWhitespace is a run of whitespace.
+	TokenWhitespace
+)
+
+var tokenKindNames = map[TokenKind]string{
+	TokenPunctuation: "punctuation",
+	TokenString:      "string",
+	TokenNumber:      "number",
+	TokenKeyword:     "keyword",
+	TokenSymbol:      "symbol",
+	TokenComment:     "comment",
+	TokenWhitespace:  "whitespace",
+}
+
+func (k TokenKind) String() string {
+	return tokenKindNames[k]
+}
+
+// Token is a lexical token of the text as seen by the repair.
+type Token struct {
+	Kind TokenKind
+	// Start and End are the positions of the token in the text. A token
+	// which is missing in the text and has been inserted by the repair, like
+	// a missing comma or closing bracket, has Start equal to End.
+	Start int
+	End   int
+	// Value is the text of the token, or the inserted text for a token which
+	// is missing in the text.
+	Value string
+	// Repaired is set when the token is changed, removed or inserted by the
+	// repair.
+	Repaired bool
+}
+
+// Tokenizer yields the tokens of a text in order, using the same lenient
+// lexing as the repair:
+//
+//	z := jsonrepair.NewTokenizer(text)
+//	for z.Next() {
+//		tok := z.Token()
+//		...
+//	}
+//	if err := z.Err(); err != nil {
+//		...
+//	}
+type Tokenizer struct {
+	tokens []Token
+	index  int
+	err    error
+}
+
+// NewTokenizer returns a Tokenizer for the given text.
+func NewTokenizer(text string) *Tokenizer {
+	tokens, err := Tokenize(text)
+	return &Tokenizer{tokens: tokens, index: -1, err: err}
+}
+
+// Next advances to the next token, and returns false when there are no more
+// tokens.
+func (z *Tokenizer) Next() bool {
+	if z.index < len(z.tokens) {
+		z.index++
+	}
+	return z.index < len(z.tokens)
+}
+
+// Token returns the current token.
+func (z *Tokenizer) Token() Token {
+	return z.tokens[z.index]
+}
+
+// Err returns the error which stopped the tokenizer when the text cannot be
+// repaired. The tokens before the position of the error are yielded anyway.
+func (z *Tokenizer) Err() error {
+	return z.err
+}
+
+// Tokenize returns all tokens of the text. When the text cannot be repaired,
+// the tokens before the position of the error are returned together with the
+// error.
+func Tokenize(text string) ([]Token, error) {
+	t, err := tokenize(text)
+	return t.sortedTokens(), err
+}
+
+// tokenize repairs the text and records its tokens.
+func tokenize(text string) (*RepairText, error) {
+	t := newRepairText(text, nil)
+	t.opts.tokens = true
+	return t, t.repair()
+}
+
+// token records a token of the text between start and end.
+func (t *RepairText) token(kind TokenKind, start, end int, repaired bool) {
+	if !t.opts.tokens {
+		return
+	}
+	start, end = min(start, len(t.text)), min(end, len(t.text))
+	t.tokens = append(t.tokens, Token{
+		Kind:     kind,
+		Start:    start,
+		End:      end,
+		Value:    string(t.text[start:end]),
+		Repaired: repaired,
+	})
+}
+
+// insertToken records a token which has been inserted into buffer at the
+// given index.
+func (t *RepairText) insertToken(kind TokenKind, value string, buffer *outputBuffer, index int) {
+	if !t.opts.tokens {
+		return
+	}
+	pos := buffer.inputOffset(index)
+	t.tokens = append(t.tokens, Token{
+		Kind:     kind,
+		Start:    pos,
+		End:      pos,
+		Value:    value,
+		Repaired: true,
+	})
+}
+
+// stripToken marks the comma token originating from the given position in
+// the text as removed, or forgets the last inserted comma when source is -1.
+func (t *RepairText) stripToken(source int) {
+	for i := len(t.tokens) - 1; i >= 0; i-- {
+		tok := t.tokens[i]
+		if tok.Kind != TokenPunctuation || tok.Value != "," {
+			continue
+		}
+		if source < 0 && tok.Start == tok.End {
+			t.tokens = append(t.tokens[:i], t.tokens[i+1:]...)
+			return
+		}
+		if source >= 0 && tok.Start == source {
+			t.tokens[i].Repaired = true
+			return
+		}
+	}
+}
+
+// sortedTokens returns the recorded tokens in the order of the text. An
+// inserted token comes before a token of the text at the same position.
+func (t *RepairText) sortedTokens() []Token {
+	tokens := append([]Token{}, t.tokens...)
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if tokens[i].Start != tokens[j].Start {
+			return tokens[i].Start < tokens[j].Start
+		}
+		return tokens[i].End < tokens[j].End
+	})
+	return tokens
+}
@@ -0,0 +1,158 @@
+package jsonrepair
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	ts := []struct {
+		Input  string
+		Tokens []Token
+	}{
+		{`{"a": 1}`, []Token{
+			{TokenPunctuation, 0, 1, "{", false},
+			{TokenString, 1, 4, `"a"`, false},
+			{TokenPunctuation, 4, 5, ":", false},
+			{TokenWhitespace, 5, 6, " ", false},
+			{TokenNumber, 6, 7, "1", false},
+			{TokenPunctuation, 7, 8, "}", false},
+		}},
+		{"{a:'b', // c\n \"d\": [1 2,],}", []Token{
+			{TokenPunctuation, 0, 1, "{", false},
+			{TokenSymbol, 1, 2, "a", true},
+			{TokenPunctuation, 2, 3, ":", false},
+			{TokenString, 3, 6, "'b'", true},
+			{TokenPunctuation, 6, 7, ",", false},
+			{TokenWhitespace, 7, 8, " ", false},
+			{TokenComment, 8, 12, "// c", true},
+			{TokenWhitespace, 12, 14, "\n ", false},
+			{TokenString, 14, 17, `"d"`, false},
+			{TokenPunctuation, 17, 18, ":", false},
+			{TokenWhitespace, 18, 19, " ", false},
+			{TokenPunctuation, 19, 20, "[", false},
+			{TokenNumber, 20, 21, "1", false},
+			{TokenPunctuation, 21, 21, ",", true},
+			{TokenWhitespace, 21, 22, " ", false},
+			{TokenNumber, 22, 23, "2", false},
+			{TokenPunctuation, 23, 24, ",", true},
+			{TokenPunctuation, 24, 25, "]", false},
+			{TokenPunctuation, 25, 26, ",", true},
+			{TokenPunctuation, 26, 27, "}", false},
+		}},
+		{`callback([None]);`, []Token{
+			{TokenSymbol, 0, 8, "callback", true},
+			{TokenPunctuation, 8, 9, "(", true},
+			{TokenPunctuation, 9, 10, "[", false},
+			{TokenKeyword, 10, 14, "None", true},
+			{TokenPunctuation, 14, 15, "]", false},
+			{TokenPunctuation, 15, 16, ")", true},
+			{TokenPunctuation, 16, 17, ";", true},
+		}},
+		{"1\n2", []Token{
+			{TokenPunctuation, 0, 0, "[", true},
+			{TokenNumber, 0, 1, "1", false},
+			{TokenPunctuation, 1, 1, ",", true},
+			{TokenWhitespace, 1, 2, "\n", false},
+			{TokenNumber, 2, 3, "2", false},
+			{TokenPunctuation, 3, 3, "]", true},
+		}},
+		{`"a"+'b'`, []Token{
+			{TokenString, 0, 3, `"a"`, false},
+			{TokenPunctuation, 3, 4, "+", true},
+			{TokenString, 4, 7, "'b'", true},
+		}},
+		{"[007, \"ab", []Token{
+			{TokenPunctuation, 0, 1, "[", false},
+			{TokenNumber, 1, 4, "007", true},
+			{TokenPunctuation, 4, 5, ",", false},
+			{TokenWhitespace, 5, 6, " ", true},
+			{TokenString, 6, 9, `"ab`, true},
+			{TokenPunctuation, 9, 9, "]", true},
+		}},
+		{`{"a" 2.`, []Token{
+			{TokenPunctuation, 0, 1, "{", false},
+			{TokenString, 1, 4, `"a"`, false},
+			{TokenPunctuation, 4, 4, ":", true},
+			{TokenWhitespace, 4, 5, " ", false},
+			{TokenNumber, 5, 7, "2.", true},
+			{TokenPunctuation, 7, 7, "}", true},
+		}},
+		{`{"a":`, []Token{
+			{TokenPunctuation, 0, 1, "{", false},
+			{TokenString, 1, 4, `"a"`, false},
+			{TokenPunctuation, 4, 5, ":", false},
+			{TokenKeyword, 5, 5, "null", true},
+			{TokenPunctuation, 5, 5, "}", true},
+		}},
+		{`[1]]`, []Token{
+			{TokenPunctuation, 0, 1, "[", false},
+			{TokenNumber, 1, 2, "1", false},
+			{TokenPunctuation, 2, 3, "]", false},
+			{TokenPunctuation, 3, 4, "]", true},
+		}},
+	}
+
+	for _, tt := range ts {
+		tokens, err := Tokenize(tt.Input)
+		if err != nil {
+			t.Errorf("input: %q, err: %v", tt.Input, err)
+			continue
+		}
+		if !reflect.DeepEqual(tokens, tt.Tokens) {
+			t.Errorf("input: %q\nexpected: %+v\ngot:      %+v", tt.Input, tt.Tokens, tokens)
+		}
+	}
+}
+
+func TestTokenizeCoversText(t *testing.T) {
+	for _, text := range []string{
+		`{"a":"foo "bar" baz"}`,
+		`[a","b"]`,
+		`\"hello\"`,
+		"/* a */ {\"b\": undefined, 'c': True} // d",
+		"{\"a\":\"b\n\"}",
+		`[1,2,3,`,
+		`{"a":\1}`,
+	} {
+		tokens, err := Tokenize(text)
+		if err != nil {
+			t.Errorf("input: %q, err: %v", text, err)
+			continue
+		}
+		// the tokens of the text follow each other, inserted tokens may be
+		// placed anywhere
+		end := 0
+		for _, tok := range tokens {
+			if tok.Start == tok.End {
+				continue
+			}
+			if tok.Start != end {
+				t.Errorf("input: %q, token %+v does not start at %d", text, tok, end)
+				break
+			}
+			end = tok.End
+		}
+		if end != len([]rune(text)) {
+			t.Errorf("input: %q, tokens end at %d", text, end)
+		}
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	z := NewTokenizer(`[1, {:2}`)
+	var values []string
+	for z.Next() {
+		values = append(values, z.Token().Value)
+	}
+	expected := []string{"[", "1", ",", " ", "{"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %q, got %q", expected, values)
+	}
+	if z.Err() == nil {
+		t.Errorf("expected an error")
+	}
+	if z.Next() {
+		t.Errorf("expected no more tokens")
+	}
+}
@@ -0,0 +1,401 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// NodeKind is the type of the value of a Node.
+type NodeKind int
+
+const (
+	NodeObject NodeKind = iota
+	NodeArray
+	NodeString
+	NodeNumber
+	NodeBoolean
+	NodeNull
+)
+
+var nodeKindNames = map[NodeKind]string{
+	NodeObject:  "object",
+	NodeArray:   "array",
+	NodeString:  "string",
+	NodeNumber:  "number",
+	NodeBoolean: "boolean",
+	NodeNull:    "null",
+}
+
+func (k NodeKind) String() string {
+	return nodeKindNames[k]
+}
+
+// Node is a value in the tree of a repaired document, see ParseTree.
+type Node struct {
+	Kind NodeKind
+	// Key is the key of an object member.
+	Key string
+	// Value is the repaired JSON text of a string, number, boolean or null,
+	// for example `"b"` for the text `'b'`.
+	Value string
+	// Children holds the elements of an array, or the members of an object.
+	Children []*Node
+
+	// Start and End are the positions of the value in the text. KeyStart
+	// and KeyEnd are the positions of the key of an object member.
+	Start    int
+	End      int
+	KeyStart int
+	KeyEnd   int
+
+	// Comments holds the comments on the lines before the node,
+	// TrailingComments the comments after the node on the same line, and
+	// EndComments the comments of an object or array after its last child.
+	Comments         []string
+	TrailingComments []string
+	EndComments      []string
+
+	// Repairs lists the repairs applied to the node, but not to one of its
+	// children.
+	Repairs []RepairAction
+}
+
+// ParseTree repairs the text and returns the repaired document as a tree,
+// with the positions of the values in the text, the comments of the text
+// attached to the nodes, and the applied repairs. The tree holds the values
+// of the output of JSONRepair, newline delimited JSON as an array. A text
+// without any value, like a function call without argument, is null.
+func ParseTree(text string) (*Node, error) {
+	t := newRepairText(text, nil)
+	t.opts.tokens = true
+	b := &treeBuilder{t: t, closed: map[*Node]bool{}}
+	t.handler = b
+	if err := t.repair(); err != nil {
+		return nil, err
+	}
+	root := b.root()
+	c := &commentScanner{tokens: t.sortedTokens(), closed: b.closed}
+	sameLine, below := c.gap(root.Start)
+	root.Comments = append(sameLine, below...)
+	c.attach(root)
+	sameLine, below = c.gap(len(t.text) + 1)
+	root.TrailingComments = append(sameLine, below...)
+	for _, r := range t.sortedRepairs() {
+		root.attach(r)
+	}
+	return root, nil
+}
+
+// Lookup returns the node at the given JSON Pointer, or nil when there is no
+// such node. When an object has duplicate keys, the last member is returned.
+func (n *Node) Lookup(pointer string) *Node {
+	if pointer == "" {
+		return n
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil
+	}
+	node := n
+	for _, segment := range strings.Split(pointer[1:], "/") {
+		segment = strings.ReplaceAll(segment, "~1", "/")
+		segment = strings.ReplaceAll(segment, "~0", "~")
+		var next *Node
+		switch node.Kind {
+		case NodeObject:
+			for _, child := range node.Children {
+				if child.Key == segment {
+					next = child
+				}
+			}
+		case NodeArray:
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Children) {
+				next = node.Children[index]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// attach adds the repair to the innermost node containing it.
+func (n *Node) attach(r RepairAction) {
+	for _, child := range n.Children {
+		start := child.Start
+		if n.Kind == NodeObject {
+			start = child.KeyStart
+		}
+		if start <= r.Start && r.End <= child.End && (r.Start < child.End || child.Start == child.End) {
+			child.attach(r)
+			return
+		}
+	}
+	n.Repairs = append(n.Repairs, r)
+}
+
+// JSON serializes the tree to JSON, without comments. With an empty indent
+// the output is compact, otherwise every child is written on its own line.
+func (n *Node) JSON(indent string) string {
+	var sb strings.Builder
+	n.write(&sb, indent, 0, false)
+	return sb.String()
+}
+
+// JSONC serializes the tree to JSON with comments. Every child is written on
+// its own line, indented with two spaces when indent is empty.
+func (n *Node) JSONC(indent string) string {
+	if indent == "" {
+		indent = "  "
+	}
+	var sb strings.Builder
+	writeComments(&sb, n.Comments, indent, 0)
+	n.write(&sb, indent, 0, true)
+	writeTrailingComments(&sb, n.TrailingComments)
+	return sb.String()
+}
+
+func (n *Node) write(sb *strings.Builder, indent string, depth int, comments bool) {
+	if n.Kind != NodeObject && n.Kind != NodeArray {
+		sb.WriteString(n.Value)
+		return
+	}
+	open, end := "[", "]"
+	if n.Kind == NodeObject {
+		open, end = "{", "}"
+	}
+	sb.WriteString(open)
+	if len(n.Children) == 0 && (!comments || len(n.EndComments) == 0) {
+		sb.WriteString(end)
+		return
+	}
+	for i, child := range n.Children {
+		if indent != "" {
+			sb.WriteString("\n")
+			if comments {
+				writeComments(sb, child.Comments, indent, depth+1)
+			}
+			sb.WriteString(strings.Repeat(indent, depth+1))
+		}
+		if n.Kind == NodeObject {
+			sb.WriteString(quote(child.Key))
+			sb.WriteString(":")
+			if indent != "" {
+				sb.WriteString(" ")
+			}
+		}
+		child.write(sb, indent, depth+1, comments)
+		if i < len(n.Children)-1 {
+			sb.WriteString(",")
+		}
+		if comments {
+			writeTrailingComments(sb, child.TrailingComments)
+		}
+	}
+	if indent != "" {
+		sb.WriteString("\n")
+		if comments {
+			writeComments(sb, n.EndComments, indent, depth+1)
+		}
+		sb.WriteString(strings.Repeat(indent, depth))
+	}
+	sb.WriteString(end)
+}
+
+func writeComments(sb *strings.Builder, comments []string, indent string, depth int) {
+	for _, comment := range comments {
+		sb.WriteString(strings.Repeat(indent, depth))
+		sb.WriteString(comment)
+		sb.WriteString("\n")
+	}
+}
+
+func writeTrailingComments(sb *strings.Builder, comments []string) {
+	for _, comment := range comments {
+		sb.WriteString(" ")
+		sb.WriteString(comment)
+	}
+}
+
+// quote returns s as JSON string, without escaping HTML characters.
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// treeBuilder builds a tree from the events of the parser, with the
+// positions of the values taken from the parser.
+type treeBuilder struct {
+	BaseHandler
+	t      *RepairText
+	roots  []*Node
+	stack  []*Node
+	closed map[*Node]bool
+	// key is the key of the next member of the innermost object, if any
+	key *Node
+}
+
+func (b *treeBuilder) OnObjectStart() { b.open(NodeObject) }
+func (b *treeBuilder) OnArrayStart()  { b.open(NodeArray) }
+func (b *treeBuilder) OnObjectEnd()   { b.close() }
+func (b *treeBuilder) OnArrayEnd()    { b.close() }
+
+func (b *treeBuilder) OnKey(key string) {
+	b.key = &Node{Key: key, KeyStart: b.t.valueStart, KeyEnd: b.t.i}
+}
+
+func (b *treeBuilder) OnValue(kind NodeKind, value string) {
+	// an inserted value starts where the parser is
+	start := min(b.t.valueStart, b.t.i)
+	b.add(&Node{Kind: kind, Value: value, Start: start, End: b.t.i})
+}
+
+func (b *treeBuilder) open(kind NodeKind) {
+	node := &Node{Kind: kind, Start: b.t.i, End: b.t.i + 1}
+	b.add(node)
+	b.stack = append(b.stack, node)
+}
+
+func (b *treeBuilder) close() {
+	node := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	b.key = nil
+	// the last token is the closing bracket, or an inserted one
+	if tok := b.t.tokens[len(b.t.tokens)-1]; tok.End > tok.Start {
+		node.End = tok.End
+		b.closed[node] = true
+	} else if n := len(node.Children); n > 0 {
+		node.End = node.Children[n-1].End
+	}
+}
+
+// add adds the node to the innermost array or object, or as root value. A
+// member of an object gets the key passed before.
+func (b *treeBuilder) add(node *Node) {
+	if len(b.stack) == 0 {
+		b.roots = append(b.roots, node)
+		return
+	}
+	parent := b.stack[len(b.stack)-1]
+	if parent.Kind == NodeObject {
+		if b.key == nil {
+			return
+		}
+		node.Key, node.KeyStart, node.KeyEnd = b.key.Key, b.key.KeyStart, b.key.KeyEnd
+		b.key = nil
+	}
+	parent.Children = append(parent.Children, node)
+}
+
+// root returns the root value, an array for newline delimited JSON.
+func (b *treeBuilder) root() *Node {
+	switch len(b.roots) {
+	case 0:
+		return &Node{Kind: NodeNull, Value: "null"}
+	case 1:
+		return b.roots[0]
+	}
+	return &Node{
+		Kind:     NodeArray,
+		Children: b.roots,
+		Start:    b.roots[0].Start,
+		End:      b.roots[len(b.roots)-1].End,
+	}
+}
+
+// commentScanner attaches the comment tokens of a text to the nodes of its
+// tree, walking the tree and the tokens in the order of the text.
+type commentScanner struct {
+	tokens []Token
+	p      int
+	closed map[*Node]bool
+}
+
+// gap skips the tokens before pos, and returns the comments before the first
+// newline and those after it.
+func (c *commentScanner) gap(pos int) (sameLine, below []string) {
+	newline := false
+	for ; c.p < len(c.tokens) && c.tokens[c.p].Start < pos; c.p++ {
+		tok := c.tokens[c.p]
+		switch tok.Kind {
+		case TokenWhitespace:
+			newline = newline || strings.ContainsAny(tok.Value, "\n\r")
+		case TokenComment:
+			if newline {
+				below = append(below, tok.Value)
+			} else {
+				sameLine = append(sameLine, tok.Value)
+			}
+			newline = newline || strings.HasPrefix(tok.Value, "//")
+		}
+	}
+	return sameLine, below
+}
+
+// attach attaches the comments inside the node to the node and its
+// children.
+func (c *commentScanner) attach(node *Node) {
+	if node.Kind != NodeObject && node.Kind != NodeArray {
+		// comments within a value, like between concatenated strings, are
+		// dropped
+		c.gap(node.End)
+		return
+	}
+	var last *Node
+	var leading []string
+	for _, child := range node.Children {
+		start := child.Start
+		if node.Kind == NodeObject {
+			start = child.KeyStart
+		}
+		sameLine, below := c.gap(start)
+		if last != nil {
+			last.TrailingComments = append(last.TrailingComments, sameLine...)
+		} else {
+			below = append(sameLine, below...)
+		}
+		leading = append(leading, below...)
+		if node.Kind == NodeObject {
+			// comments between the key and the value
+			c.gap(child.KeyEnd)
+			sameLine, below := c.gap(child.Start)
+			leading = append(append(leading, sameLine...), below...)
+		}
+		child.Comments = leading
+		leading = nil
+		c.attach(child)
+		last = child
+	}
+	if !c.closed[node] {
+		return
+	}
+	sameLine, below := c.gap(node.End - 1)
+	if last != nil {
+		last.TrailingComments = append(last.TrailingComments, sameLine...)
+	} else {
+		below = append(sameLine, below...)
+	}
+	node.EndComments = below
+	c.gap(node.End)
+}
+
+// valueKind returns the kind of the JSON text of a string, number, boolean
+// or null.
+func valueKind(value string) NodeKind {
+	switch value[0] {
+	case '"':
+		return NodeString
+	case 't', 'f':
+		return NodeBoolean
+	case 'n':
+		return NodeNull
+	default:
+		return NodeNumber
+	}
+}
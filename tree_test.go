@@ -0,0 +1,155 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestParseTreeOutput(t *testing.T) {
+	for _, text := range []string{
+		`{"a":1}`,
+		"{a:'b', // c\n \"d\": [1 2,],}",
+		`callback({"a":None});`,
+		"1\n2\n{\"a\":3}",
+		`"a" + 'b' + "c"`,
+		`[007, "ab`,
+		`{"a" 2.`,
+		`{"a":`,
+		`[1]]`,
+		`[a","b"]`,
+		`\"hello\"`,
+		"/* a */ {\"b\": undefined, 'c': True} // d",
+		"{\"a\":\"b\n\"}",
+		`{"a":"foo "bar" baz"}`,
+		`{"html": "<a href=\"x\">"}`,
+		`{"a": x&y "b": 1}`,
+		"[1\x01}",
+		`[]`,
+		`{}`,
+		`['b'+true]`,
+		"'b'// y\n++\"a\"",
+		`[f()]`,
+	} {
+		expected, err := JSONRepair(text)
+		if err != nil {
+			t.Errorf("input: %q, err: %v", text, err)
+			continue
+		}
+		node, err := ParseTree(text)
+		if err != nil {
+			t.Errorf("input: %q, err: %v", text, err)
+			continue
+		}
+		for _, indent := range []string{"", "  "} {
+			var want, got any
+			json.Unmarshal([]byte(expected), &want)
+			if err := json.Unmarshal([]byte(node.JSON(indent)), &got); err != nil {
+				t.Errorf("input: %q, invalid output %q: %v", text, node.JSON(indent), err)
+				continue
+			}
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("input: %q, expected %s, got %s", text, expected, node.JSON(indent))
+			}
+		}
+	}
+}
+
+func TestParseTreeIsRepair(t *testing.T) {
+	chars := []rune(`{}[]():,"'+-1aeE.\ /*` + "\n")
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 20000; n++ {
+		text := make([]rune, r.Intn(14))
+		for i := range text {
+			text[i] = chars[r.Intn(len(chars))]
+		}
+		expected, err := JSONRepair(string(text))
+		if err != nil || !json.Valid([]byte(expected)) {
+			continue
+		}
+		node, err := ParseTree(string(text))
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", string(text), err)
+		}
+		var want, got any
+		json.Unmarshal([]byte(expected), &want)
+		if err := json.Unmarshal([]byte(node.JSON("")), &got); err != nil || !reflect.DeepEqual(want, got) {
+			t.Fatalf("%q: expected %s, got %s", string(text), expected, node.JSON(""))
+		}
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	text := "// config\n{\n  // name\n  name: 'app', // inline\n  \"ports\": [80 443,],\n  /* end */\n}\n"
+	root, err := ParseTree(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedJSONC := "// config\n{\n  // name\n  \"name\": \"app\", // inline\n  \"ports\": [\n    80,\n    443\n  ]\n  /* end */\n}"
+	if got := root.JSONC(""); got != expectedJSONC {
+		t.Errorf("expected JSONC:\n%s\ngot:\n%s", expectedJSONC, got)
+	}
+	if got := root.JSON(""); got != `{"name":"app","ports":[80,443]}` {
+		t.Errorf("unexpected JSON %s", got)
+	}
+
+	name := root.Lookup("/name")
+	if name == nil || name.Kind != NodeString || name.Value != `"app"` || name.Start != 30 || name.End != 35 || name.KeyStart != 24 || name.KeyEnd != 28 {
+		t.Fatalf("unexpected node %+v", name)
+	}
+	if !reflect.DeepEqual(name.Comments, []string{"// name"}) || !reflect.DeepEqual(name.TrailingComments, []string{"// inline"}) {
+		t.Errorf("unexpected comments %q %q", name.Comments, name.TrailingComments)
+	}
+	if expected := []RepairAction{{RepairUnquotedKey, 24, 28}, {RepairQuotes, 30, 35}}; !reflect.DeepEqual(name.Repairs, expected) {
+		t.Errorf("expected repairs %v, got %v", expected, name.Repairs)
+	}
+
+	ports := root.Lookup("/ports")
+	if ports == nil || ports.Kind != NodeArray || ports.Start != 58 || ports.End != 67 || len(ports.Children) != 2 {
+		t.Fatalf("unexpected node %+v", ports)
+	}
+	if expected := []RepairAction{{RepairMissingComma, 61, 61}, {RepairTrailingComma, 65, 66}}; !reflect.DeepEqual(ports.Repairs, expected) {
+		t.Errorf("expected repairs %v, got %v", expected, ports.Repairs)
+	}
+	if port := root.Lookup("/ports/1"); port == nil || port.Value != "443" || port.Kind != NodeNumber {
+		t.Errorf("unexpected node %+v", port)
+	}
+	if root.Lookup("/ports/2") != nil || root.Lookup("/missing") != nil || root.Lookup("name") != nil {
+		t.Errorf("expected no node")
+	}
+	if !reflect.DeepEqual(root.EndComments, []string{"/* end */"}) {
+		t.Errorf("unexpected end comments %q", root.EndComments)
+	}
+
+	// the tree can be changed and written back
+	ports.Children = append(ports.Children, &Node{Kind: NodeNumber, Value: "8080"})
+	name.Value = `"service"`
+	if got := root.JSON(""); got != `{"name":"service","ports":[80,443,8080]}` {
+		t.Errorf("unexpected JSON %s", got)
+	}
+
+	if _, err := ParseTree(`{"a":2}foo`); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestParseTreeFunctionCall(t *testing.T) {
+	for text, expected := range map[string]string{
+		`callback();`:    `null`,
+		`[f()]`:          `[]`,
+		`[f(]`:           `[]`,
+		`{"a": f()}`:     `{}`,
+		`callback([1]);`: `[1]`,
+	} {
+		root, err := ParseTree(text)
+		if err != nil {
+			t.Errorf("input: %q, err: %v", text, err)
+			continue
+		}
+		if got := root.JSON(""); got != expected {
+			t.Errorf("input: %q, expected %s, got %s", text, expected, got)
+		}
+	}
+}